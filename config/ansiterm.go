@@ -3,47 +3,53 @@ package config
 import (
 	"bytes"
 	"io"
-)
 
-// 0, Black; 1, Red; 2, Green; 3, Yellow; 4, Blue; 5, Purple; 6, Cyan; 7, White
-var (
-	colorDebug = []byte("\033[32m")
-	colorTrace = []byte("\033[35m")
-	colorWarn  = []byte("\033[33m")
-	colorError = []byte("\033[31m")
-	colorReset = []byte("\033[0m")
+	"github.com/ccpaging/log/multi"
 )
 
+var colorReset = []byte("\033[0m")
+
+// levelColor returns the color of the level, see multi.RegisterLevel.
+func levelColor(level string) []byte {
+	def, _ := multi.LookupLevel(level)
+	return []byte(def.Color)
+}
+
+// colorLine appends line in color to b.
+func colorLine(b, color, line []byte) []byte {
+	b = append(b, color...)
+	b = append(b, bytes.Trim(line, "\r\n")...)
+	b = append(b, colorReset...)
+	return append(b, '\n')
+}
+
+// ansiTerm writes the lines of a single level in its color.
 type ansiTerm struct {
-	w io.Writer
+	w     io.Writer
+	color []byte
 }
 
 func (t *ansiTerm) Write(b []byte) (n int, err error) {
 	n = len(b)
-	var cb []byte
-	if n > 20 {
-		switch b[20] {
-		case 'D':
-			cb = colorDebug
-		case 'T':
-			cb = colorTrace
-		case 'W':
-			cb = colorWarn
-		case 'E':
-			cb = colorError
-		case 'F':
-			cb = colorError
-		}
-	}
-	if len(cb) == 0 {
+	if len(t.color) == 0 {
 		_, err = t.w.Write(b)
 		return
 	}
-	var bb []byte
-	bb = append(bb, cb...)
-	bb = append(bb, bytes.Trim(b, "\r\n")...)
-	bb = append(bb, colorReset...)
-	bb = append(bb, '\n')
-	_, err = t.w.Write(bb)
+	_, err = t.w.Write(colorLine(nil, t.color, b))
 	return
 }
+
+// ansiFormatter colors the records encoded by Formatter by their level.
+type ansiFormatter struct {
+	multi.Formatter
+}
+
+func (f ansiFormatter) Format(b []byte, r *multi.Record) []byte {
+	color := levelColor(r.Level)
+	if len(color) == 0 {
+		return f.Formatter.Format(b, r)
+	}
+	n := len(b)
+	line := f.Formatter.Format(b, r)[n:]
+	return colorLine(b[:n], color, append([]byte(nil), line...))
+}
@@ -7,65 +7,160 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ccpaging/log/file"
 	"github.com/ccpaging/log/multi"
 )
 
-var levelStrings = multi.LevelStrings
 var LstdFlags = log.LstdFlags
 
-func ltoi(s string) int {
-	switch strings.ToLower(strings.Trim(s, " \r\n")) {
-	case "trace", multi.Ltrace:
-		return 0
-	case "debug", multi.Ldebug:
-		return 1
-	case "info", multi.Linfo:
-		return 2
-	case "warn", "warning", multi.Lwarn:
-		return 3
-	case "err", "error", multi.Lerror:
-		return 4
-	case "fatal", multi.Lfatal:
-		return 5
-	default:
-	}
-	return 2
+// ltos returns the level string of the level name s, multi.Linfo if
+// s is unknown.
+func ltos(s string) string {
+	if level, ok := multi.ParseLevel(s); ok {
+		return level
+	}
+	return multi.Linfo
+}
+
+// severity returns the severity of the level string.
+func severity(level string) int32 {
+	def, _ := multi.LookupLevel(level)
+	return def.Severity
+}
+
+// sinks are the outputs of a config, replaced as a whole by
+// Builder.Reconfigure.
+type sinks struct {
+	list []sink
+	outs map[string]multi.Tee
+
+	modules map[string]ModuleConfig
+	views   sync.Map // the sinks of the modules by name
 }
 
+// Builder creates the loggers of a config. The loggers write to the
+// current sinks of the builder, so Reconfigure changes all of them.
 type Builder struct {
-	cw  io.Writer
-	fw  *file.File
-	cal int // the level index of console output
-	fal int // the level index of file output
+	mu sync.RWMutex // held for writing while the sinks are replaced
+	s  *sinks
+	c  Config
+
+	// the loggers created by Logger by module, so Reconfigure changes
+	// their levels
+	loggers map[string][]*multi.Multi
 }
 
 func NewBuilder(c *Config) *Builder {
 	if c == nil {
 		c = Default()
 	}
-	return &Builder{
-		cw:  newConsoleWriter(c),
-		fw:  newFileWriter(c),
-		cal: ltoi(c.ConsoleLevel),
-		fal: ltoi(c.FileLevel),
+	b := &Builder{c: *c, loggers: make(map[string][]*multi.Multi)}
+	b.s = newSinks(c, nil)
+	return b
+}
+
+// newSinks creates the sinks of the config. The files of the old sinks
+// are reused if the locations are not changed.
+func newSinks(c *Config, old *sinks) *sinks {
+	files := make(map[string]*file.File)
+	if old != nil {
+		for _, fw := range old.files() {
+			files[fw.FilePath] = fw
+		}
 	}
 
+	s := &sinks{modules: c.Modules}
+	if c.EnableConsole {
+		min, _ := levelRange(ltos(c.ConsoleLevel), "")
+		sk := consoleSink(os.Stderr, min, math.MaxInt32, c.ConsoleFormat, c.ConsoleAnsiColor)
+		sk.kind = consoleKind
+		s.list = append(s.list, sk)
+	}
+	if fw := newFileWriter(c, files); fw != nil {
+		min, _ := levelRange(ltos(c.FileLevel), "")
+		sk := fileSink(fw, min, math.MaxInt32, c.FileFormat)
+		sk.kind = fileKind
+		s.list = append(s.list, sk)
+	}
+	for i := range c.Sinks {
+		sk, err := newSink(&c.Sinks[i], files)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Sink", c.Sinks[i].Name, err)
+			continue
+		}
+		sk.name = c.Sinks[i].Name
+		s.list = append(s.list, sk)
+	}
+	s.index()
+	return s
 }
 
-func newConsoleWriter(c *Config) io.Writer {
-	if !c.EnableConsole {
-		return nil
+// minLevel returns the lowest level written by the sinks, or the
+// highest level if none.
+func (s *sinks) minLevel() string {
+	min, max := "", ""
+	for _, level := range multi.LevelStrings {
+		n := severity(level)
+		if len(s.outs[level]) > 0 && (min == "" || n < severity(min)) {
+			min = level
+		}
+		if max == "" || n > severity(max) {
+			max = level
+		}
+	}
+	if min == "" {
+		return max
 	}
-	if c.ConsoleAnsiColor {
-		return &ansiTerm{os.Stderr}
+	return min
+}
+
+// index computes the outputs of the levels.
+func (s *sinks) index() {
+	s.outs = nil
+	outs := make(map[string]multi.Tee, len(multi.LevelStrings))
+	for _, level := range multi.LevelStrings {
+		outs[level] = s.levelOutput(level)
 	}
-	return os.Stderr
+	s.outs = outs
+}
+
+// closers returns the distinct closers of the sinks.
+func (s *sinks) closers() (closers []io.Closer) {
+	for _, sk := range s.list {
+		if sk.closer != nil && !slices.Contains(closers, sk.closer) {
+			closers = append(closers, sk.closer)
+		}
+	}
+	return
+}
+
+// files returns the distinct files of the sinks.
+func (s *sinks) files() (files []*file.File) {
+	for _, sk := range s.list {
+		if sk.fw != nil && !slices.Contains(files, sk.fw) {
+			files = append(files, sk.fw)
+		}
+	}
+	return
+}
+
+// newFormatter returns the formatter named "text", "json" or "logfmt".
+func newFormatter(name string) multi.Formatter {
+	switch strings.ToLower(name) {
+	case "json":
+		return &multi.JSONFormatter{}
+	case "logfmt":
+		return &multi.LogfmtFormatter{}
+	}
+	return &multi.TextFormatter{Flags: LstdFlags}
 }
 
 func strToNumSuffix(s string, base int64) (int64, error) {
@@ -87,61 +182,191 @@ func strToNumSuffix(s string, base int64) (int64, error) {
 	return n * multi, err
 }
 
-func newFileWriter(c *Config) *file.File {
+// newFileWriter opens the file of the config, or returns the one in
+// files with the limits of the config.
+func newFileWriter(c *Config, files map[string]*file.File) *file.File {
 	if !c.EnableFile {
 		return nil
 	}
 	limitSize, _ := strToNumSuffix(c.FileLimitSize, 1024)
-	if c.FileLocation == "" {
+	location := c.FileLocation
+	if location == "" {
 		fileName := os.Args[0]
 		ext := filepath.Ext(fileName)
-		c.FileLocation = fileName[0:len(fileName)-len(ext)] + "." + "log"
+		location = fileName[0:len(fileName)-len(ext)] + "." + "log"
 	}
-	fw, err := file.OpenFile(c.FileLocation, limitSize, c.FileBackupCount)
+	fw, err := openFile(location, fileOptions{limitSize: limitSize, backupFiles: c.FileBackupCount}, files)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Open file", err)
+		return nil
 	}
 	return fw
 }
 
-func (b *Builder) levelWriter(n int) io.Writer {
-	isConsole := false
-	if b.cw != nil && n >= b.cal {
-		isConsole = true
+func (s *sinks) levelWriter(level string) io.Writer {
+	n := severity(level)
+	var ws []io.Writer
+	for _, sk := range s.list {
+		if sk.has(n) {
+			ws = append(ws, sk.writer(level))
+		}
 	}
-	isFile := false
-	if b.fw != nil && n >= b.fal {
-		isFile = true
+	switch len(ws) {
+	case 0:
+		return nil
+	case 1:
+		return ws[0]
 	}
-	if isConsole && isFile {
-		return io.MultiWriter(b.cw, b.fw)
-	} else if isConsole {
-		return b.cw
-	} else if isFile {
-		return b.fw
+	return io.MultiWriter(ws...)
+}
+
+func (s *sinks) levelOutput(level string) multi.Tee {
+	if outs, ok := s.outs[level]; ok {
+		return outs
+	}
+	n := severity(level)
+	var outs multi.Tee
+	for _, sk := range s.list {
+		if sk.has(n) {
+			outs = append(outs, sk.out)
+		}
+	}
+	return outs
+}
+
+// route is the output of a level of the loggers created by Logger. It
+// writes to the current sinks of the builder.
+type route struct {
+	b      *Builder
+	level  string
+	module string
+}
+
+func (r route) Output(calldepth int, s string) error {
+	r.b.mu.RLock()
+	defer r.b.mu.RUnlock()
+
+	if outs := r.b.s.view(r.module).levelOutput(r.level); outs != nil {
+		return outs.Output(1+calldepth, s)
 	}
 	return nil
 }
 
-func (b *Builder) Logger(name string) *multi.Multi {
-	multi := multi.Omitter(name)
-	for i, k := range levelStrings {
-		if w := b.levelWriter(i); w != nil {
-			multi.SetOutput(k, log.New(w, "", log.LstdFlags))
-		}
+func (r route) OutputRecord(calldepth int, rec *multi.Record) error {
+	r.b.mu.RLock()
+	defer r.b.mu.RUnlock()
+
+	if outs := r.b.s.view(r.module).levelOutput(r.level); outs != nil {
+		return outs.OutputRecord(1+calldepth, rec)
 	}
-	if b.fw != nil {
-		multi.Closer = b.fw
+	return nil
+}
+
+// routeWriter is the writer of a level of the loggers created by
+// StdLogAt. It writes to the current sinks of the builder.
+type routeWriter struct {
+	b      *Builder
+	level  string
+	module string
+}
+
+func (w routeWriter) Write(p []byte) (int, error) {
+	w.b.mu.RLock()
+	defer w.b.mu.RUnlock()
+
+	if lw := w.b.s.view(w.module).levelWriter(w.level); lw != nil {
+		return lw.Write(p)
 	}
-	return multi
+	return len(p), nil
+}
+
+// Logger creates a logger writing to the sinks of the builder, with
+// the levels of the module named like the logger, see Config.Modules.
+// The level of the logger is the lowest level written by the sinks, so
+// Enabled is false for the discarded levels. Reconfigure sets it again.
+func (b *Builder) Logger(name string) *multi.Multi {
+	module := moduleName(name)
+	l := multi.Omitter(name)
+	for _, level := range multi.LevelStrings {
+		l.SetOutput(level, route{b, level, module})
+	}
+	l.Closer = b
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l.SetLevel(b.s.view(module).minLevel())
+	b.loggers[module] = append(b.loggers[module], l)
+	return l
 }
 
+// StdLogAt creates a log.Logger writing at the level. The logger
+// follows Reconfigure, unless the level is discarded at creation.
 func (b *Builder) StdLogAt(level, name string) *log.Logger {
-	n := ltoi(level)
-	prefix := levelStrings[n] + name
-	if w := b.levelWriter(n); w != nil {
-		return log.New(w, prefix, LstdFlags)
+	level = ltos(level)
+	prefix := level + name
+	module := moduleName(name)
+
+	b.mu.RLock()
+	w := b.s.view(module).levelWriter(level)
+	b.mu.RUnlock()
+	if w != nil {
+		return log.New(routeWriter{b, level, module}, prefix, LstdFlags)
 	}
 
 	return log.New(io.Discard, prefix, LstdFlags)
 }
+
+// Config returns the current config.
+func (b *Builder) Config() Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.c
+}
+
+// Reconfigure replaces the sinks of all the loggers created by the
+// builder with the sinks of the config. A record is written to either
+// the old or the new sinks. The files are kept if the locations are
+// not changed, and closed otherwise, like the syslog connections.
+func (b *Builder) Reconfigure(c *Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	old := b.s
+	b.s = newSinks(c, old)
+	b.c = *c
+	for module, loggers := range b.loggers {
+		level := b.s.view(module).minLevel()
+		for _, l := range loggers {
+			l.SetLevel(level)
+		}
+	}
+	closers := b.s.closers()
+	b.mu.Unlock()
+
+	var err error
+	for _, c := range old.closers() {
+		if !slices.Contains(closers, c) {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// Close closes the files and the connections of the current sinks.
+func (b *Builder) Close() (err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, c := range b.s.closers() {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
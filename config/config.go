@@ -7,12 +7,21 @@ type Config struct {
 	EnableConsole    bool
 	ConsoleLevel     string
 	ConsoleAnsiColor bool
+	ConsoleFormat    string // "text", "json" or "logfmt"
 
 	EnableFile      bool
 	FileLevel       string
+	FileFormat      string // "text", "json" or "logfmt"
 	FileLocation    string
 	FileLimitSize   string
 	FileBackupCount int
+
+	Sinks []SinkConfig // more outputs
+
+	// Modules overrides the levels of the loggers by module name, like
+	// "db.pool", or pattern, like "db.*". The exact name is preferred,
+	// then the longest pattern.
+	Modules map[string]ModuleConfig
 }
 
 func Default() *Config {
@@ -20,8 +29,10 @@ func Default() *Config {
 		EnableConsole:    true,
 		ConsoleLevel:     "debug",
 		ConsoleAnsiColor: false,
+		ConsoleFormat:    "text",
 		EnableFile:       false,
 		FileLevel:        "info",
+		FileFormat:       "text",
 		FileLocation:     "",
 		FileLimitSize:    "10M",
 		FileBackupCount:  7,
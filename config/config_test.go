@@ -89,3 +89,53 @@ func TestStdLogAt(t *testing.T) {
 	}
 	buf.Reset()
 }
+
+func TestFileFormat(t *testing.T) {
+	const jsonLogFile = "_test_json.log"
+	b := config.NewBuilder(&config.Config{
+		EnableFile:   true,
+		FileLevel:    "warn",
+		FileFormat:   "json",
+		FileLocation: jsonLogFile,
+	})
+	defer removeFile(t, jsonLogFile)
+
+	logger := b.Logger("test: ")
+	logger.Info("info log. ", "This should not be written")
+	logger.Warnw("warning log.", "key", "value")
+	logger.Close()
+
+	contents, err := os.ReadFile(jsonLogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"level":"WARN","logger":"test",`; !bytes.Contains(contents, []byte(want)) {
+		t.Errorf("\nwant: %q\ngot:  %q", want, contents)
+	}
+	if want := `"msg":"warning log.","key":"value"}` + "\n"; !bytes.HasSuffix(contents, []byte(want)) {
+		t.Errorf("\nwant: %q\ngot:  %q", want, contents)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	const redirectLogFile = "_test_redirect.log"
+	b := config.NewBuilder(&config.Config{
+		EnableFile:   true,
+		FileLevel:    "info",
+		FileLocation: redirectLogFile,
+	})
+	defer removeFile(t, redirectLogFile)
+
+	multi.Redirect(b.Logger("test: "))
+	multi.Debug("debug log. ", "This should not be written")
+	multi.Info("info log. ", "redirected")
+	multi.Restore()
+
+	contents, err := os.ReadFile(redirectLogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO root info log. redirected\n"; !bytes.HasSuffix(contents, []byte(want)) || bytes.Contains(contents, []byte("DEBUG")) {
+		t.Errorf("\nwant: %q\ngot:  %q", want, contents)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ccpaging/log/multi"
+)
+
+// Load reads the JSON file at path over the defaults, then validates
+// the config. The keys are the field names, e.g. "ConsoleLevel".
+//
+// The precedence is defaults < file < environment, so call ApplyEnv
+// on the result to override the file with the environment.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := Default()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return c, c.Validate()
+}
+
+// FromEnv returns the defaults overridden by the environment, see
+// ApplyEnv.
+func FromEnv(prefix string) (*Config, error) {
+	c := Default()
+	return c, c.ApplyEnv(prefix)
+}
+
+// envName returns the name of the variable of a field, e.g.
+// "APP_LOG_CONSOLE_LEVEL" for "APP_LOG" and "ConsoleLevel".
+func envName(prefix, field string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, r := range field {
+		if i > 0 && unicode.IsUpper(r) || i == 0 && prefix != "" {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// ApplyEnv overrides the fields with the environment variables named
+// like "APP_LOG_CONSOLE_LEVEL" for the prefix "APP_LOG", then validates
+// the config. The strings are taken as is, the other values are JSON,
+// e.g. "true" or "7".
+func (c *Config) ApplyEnv(prefix string) error {
+	var errs []error
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := envName(prefix, field.Name)
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if field.Type.Kind() == reflect.String {
+			v.Field(i).SetString(s)
+			continue
+		}
+		if err := json.Unmarshal([]byte(s), v.Field(i).Addr().Interface()); err != nil {
+			errs = append(errs, fmt.Errorf("config: %s: %w", name, err))
+		}
+	}
+	if err := c.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate returns the errors of all the bad fields, or nil.
+func (c *Config) Validate() error {
+	var errs []error
+	bad := func(field, format string, a ...any) {
+		errs = append(errs, fmt.Errorf("config: "+field+": "+format, a...))
+	}
+
+	if !isLevel(c.ConsoleLevel) {
+		bad("ConsoleLevel", "unknown level %q", c.ConsoleLevel)
+	}
+	if !isFormat(c.ConsoleFormat) {
+		bad("ConsoleFormat", "unknown format %q", c.ConsoleFormat)
+	}
+	if !isLevel(c.FileLevel) {
+		bad("FileLevel", "unknown level %q", c.FileLevel)
+	}
+	if !isFormat(c.FileFormat) {
+		bad("FileFormat", "unknown format %q", c.FileFormat)
+	}
+	if _, err := strToNumSuffix(c.FileLimitSize, 1024); err != nil && c.FileLimitSize != "" {
+		bad("FileLimitSize", "bad size %q", c.FileLimitSize)
+	}
+	if c.FileBackupCount < 0 {
+		bad("FileBackupCount", "negative count %d", c.FileBackupCount)
+	}
+	for i := range c.Sinks {
+		field := fmt.Sprintf("Sinks[%d]", i)
+		if name := c.Sinks[i].Name; name != "" {
+			field += " " + strconv.Quote(name)
+		}
+		for _, err := range c.Sinks[i].validate() {
+			bad(field, "%w", err)
+		}
+	}
+	patterns := make([]string, 0, len(c.Modules))
+	for pattern := range c.Modules {
+		patterns = append(patterns, pattern)
+	}
+	slices.Sort(patterns)
+	for _, pattern := range patterns {
+		field := fmt.Sprintf("Modules[%q]", pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			bad(field, "bad pattern")
+		}
+		mc := c.Modules[pattern]
+		for _, l := range [][2]string{
+			{"Level", mc.Level}, {"ConsoleLevel", mc.ConsoleLevel}, {"FileLevel", mc.FileLevel},
+		} {
+			if !isLevel(l[1]) {
+				bad(field, "%s: unknown level %q", l[0], l[1])
+			}
+		}
+		names := make([]string, 0, len(mc.SinkLevels))
+		for name := range mc.SinkLevels {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if !slices.ContainsFunc(c.Sinks, func(sc SinkConfig) bool { return sc.Name == name }) {
+				bad(field, "SinkLevels: unknown sink %q", name)
+			}
+			if level := mc.SinkLevels[name]; !isLevel(level) {
+				bad(field, "SinkLevels[%q]: unknown level %q", name, level)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isLevel reports whether the name is a level, or empty for info.
+func isLevel(name string) bool {
+	_, ok := multi.ParseLevel(name)
+	return ok || name == ""
+}
+
+// isFormat reports whether the name is a formatter of newFormatter.
+func isFormat(name string) bool {
+	switch strings.ToLower(name) {
+	case "", "text", "json", "logfmt":
+		return true
+	}
+	return false
+}
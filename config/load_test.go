@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/log/config"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	os.WriteFile(path, []byte(`{"ConsoleLevel": "warn", "FileBackupCount": 3}`), 0644)
+
+	t.Setenv("TEST_LOG_CONSOLE_LEVEL", "error")
+	t.Setenv("TEST_LOG_ENABLE_FILE", "true")
+
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ConsoleLevel != "warn" || c.FileBackupCount != 3 || c.FileLimitSize != "10M" {
+		t.Errorf("file: %+v", c)
+	}
+	if err := c.ApplyEnv("TEST_LOG"); err != nil {
+		t.Fatal(err)
+	}
+	if c.ConsoleLevel != "error" || !c.EnableFile || c.FileBackupCount != 3 {
+		t.Errorf("env: %+v", c)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("TEST_LOG_CONSOLE_LEVEL", "loud")
+	t.Setenv("TEST_LOG_FILE_FORMAT", "xml")
+	t.Setenv("TEST_LOG_FILE_LIMIT_SIZE", "big")
+	t.Setenv("TEST_LOG_FILE_BACKUP_COUNT", "many")
+
+	_, err := config.FromEnv("TEST_LOG")
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, field := range []string{"ConsoleLevel", "FileFormat", "FileLimitSize", "FILE_BACKUP_COUNT"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("%s is not reported: %v", field, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "log.json")
+	os.WriteFile(path, []byte(`{"ConsoleLevels": "warn"}`), 0644)
+	if _, err := config.Load(path); err == nil {
+		t.Errorf("unknown field is not reported")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// ModuleConfig overrides the levels of the config for the loggers of a
+// module, see Config.Modules. Level replaces the levels of the console
+// and the file, and the MinLevel of Sinks, whose MaxLevel is kept. A
+// sink which must keep its MinLevel, e.g. one of the errors only, is
+// set in SinkLevels.
+type ModuleConfig struct {
+	Level        string            // the lowest level of all the sinks, if not empty
+	ConsoleLevel string            // overrides Config.ConsoleLevel and Level
+	FileLevel    string            // overrides Config.FileLevel and Level
+	SinkLevels   map[string]string // overrides MinLevel and Level by SinkConfig.Name
+}
+
+// moduleName returns the module of a logger name, e.g. "db.pool" for
+// "db.pool: ".
+func moduleName(name string) string {
+	return strings.TrimSuffix(strings.TrimSpace(name), ":")
+}
+
+// module returns the config of the module by its name, or else by the
+// longest matching pattern.
+func (s *sinks) module(name string) (ModuleConfig, bool) {
+	if mc, ok := s.modules[name]; ok {
+		return mc, true
+	}
+	best, found := "", false
+	for pattern := range s.modules {
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+		if !found || len(pattern) > len(best) || len(pattern) == len(best) && pattern < best {
+			best, found = pattern, true
+		}
+	}
+	return s.modules[best], found
+}
+
+// view returns the sinks of the module, which are the sinks with the
+// levels of the module config if any.
+func (s *sinks) view(module string) *sinks {
+	if len(s.modules) == 0 {
+		return s
+	}
+	if v, ok := s.views.Load(module); ok {
+		return v.(*sinks)
+	}
+
+	v := s
+	if mc, ok := s.module(module); ok {
+		v = &sinks{list: slices.Clone(s.list)}
+		for i := range v.list {
+			sk := &v.list[i]
+			level := mc.Level
+			switch {
+			case sk.kind == consoleKind && mc.ConsoleLevel != "":
+				level = mc.ConsoleLevel
+			case sk.kind == fileKind && mc.FileLevel != "":
+				level = mc.FileLevel
+			case sk.kind == 0 && sk.name != "" && mc.SinkLevels[sk.name] != "":
+				level = mc.SinkLevels[sk.name]
+			}
+			if level == "" {
+				continue
+			}
+			sk.min, _ = levelRange(level, "")
+		}
+		v.index()
+	}
+	s.views.Store(module, v)
+	return v
+}
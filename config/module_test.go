@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/log/config"
+	"github.com/ccpaging/log/multi"
+)
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "test.log")
+	c := &config.Config{
+		EnableFile:   true,
+		FileLevel:    "info",
+		FileLocation: logPath,
+		Modules: map[string]config.ModuleConfig{
+			"db.*":    {Level: "trace"},
+			"db.pool": {FileLevel: "error"},
+			"http":    {Level: "warn"},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b := config.NewBuilder(c)
+	for _, name := range []string{"db.pool: ", "db.sql: ", "http: ", "app: "} {
+		logger := b.Logger(name)
+		logger.Trace("trace")
+		logger.Info("info")
+		logger.Error("error")
+	}
+	b.StdLogAt("trace", "db.sql: ").Print("std")
+	b.StdLogAt("trace", "app: ").Print("std")
+	b.Close()
+
+	got := mustRead(t, logPath)
+	for _, s := range []string{
+		"ERROR db.pool: error", "TRAC db.sql: trace", "INFO db.sql: info",
+		"ERROR http: error", "INFO app: info", "TRAC db.sql: ",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("missing %q in %q", s, got)
+		}
+	}
+	if n := strings.Count(got, "\n"); n != 8 {
+		t.Errorf("lines = %d, want 8: %q", n, got)
+	}
+}
+
+func TestModulesRangedSink(t *testing.T) {
+	dir := t.TempDir()
+	errorsLog, allLog, noticesLog := filepath.Join(dir, "errors.log"), filepath.Join(dir, "all.log"), filepath.Join(dir, "notices.log")
+	c := &config.Config{
+		Sinks: []config.SinkConfig{
+			{Name: "errors", Type: "file", MinLevel: "error", Options: map[string]string{"location": errorsLog}},
+			{Type: "file", MinLevel: "info", Options: map[string]string{"location": allLog}},
+			{Type: "file", MinLevel: "info", MaxLevel: "warn", Options: map[string]string{"location": noticesLog}},
+		},
+		Modules: map[string]config.ModuleConfig{
+			"db.*": {Level: "trace", SinkLevels: map[string]string{"errors": "error"}},
+			"http": {Level: "warn"},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b := config.NewBuilder(c)
+	db, http := b.Logger("db.pool: "), b.Logger("http: ")
+	if !db.Enabled(multi.Ltrace) || http.Enabled(multi.Linfo) {
+		t.Errorf("levels: db %q, http %q", db.Level(), http.Level())
+	}
+	for _, logger := range []*multi.Multi{db, http} {
+		logger.Trace("trace")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+	}
+	b.Close()
+
+	// the MinLevel of errors is kept for db by SinkLevels, replaced for http
+	if got := mustRead(t, errorsLog); strings.Count(got, "\n") != 3 ||
+		!strings.Contains(got, "ERROR db.pool: error") || !strings.Contains(got, "WARN http: warn") {
+		t.Errorf("errors: %q", got)
+	}
+	if got := mustRead(t, allLog); strings.Count(got, "\n") != 6 ||
+		!strings.Contains(got, "TRAC db.pool: trace") || strings.Contains(got, "INFO http") {
+		t.Errorf("all: %q", got)
+	}
+	// the MaxLevel of notices is kept
+	if got := mustRead(t, noticesLog); strings.Count(got, "\n") != 4 ||
+		!strings.Contains(got, "TRAC db.pool: trace") || strings.Contains(got, "ERROR") {
+		t.Errorf("notices: %q", got)
+	}
+}
+
+func TestValidateModules(t *testing.T) {
+	c := &config.Config{Modules: map[string]config.ModuleConfig{
+		"db[":  {},
+		"db.*": {ConsoleLevel: "loud"},
+		"http": {SinkLevels: map[string]string{"errors": "warn"}},
+	}}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, s := range []string{`Modules["db["]: bad pattern`, `Modules["db.*"]: ConsoleLevel: unknown level "loud"`, `Modules["http"]: SinkLevels: unknown sink "errors"`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("missing %q in %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ccpaging/log/file"
+	"github.com/ccpaging/log/multi"
+)
+
+// SinkConfig configures an output of the loggers in addition to the
+// console and the file of Config.
+//
+// The options of the type "stderr" and "stdout" are:
+//
+//	ansi_color    "true" to color the lines by level
+//
+// The options of the type "file" are:
+//
+//	location      the path of the file, required
+//	limit_size    like FileLimitSize, e.g. "100M"
+//	backup_count  like FileBackupCount
+//	compress      "true" to compress the backup files
+//	period        "hourly" or "daily" rolling
+//
+// The options of the type "syslog" are:
+//
+//	network       like syslog.Dial, the local daemon if empty
+//	address       like syslog.Dial
+//	facility      e.g. "user", the default, "daemon" or "local0"
+//	tag           like syslog.Dial, os.Args[0] if empty
+//	severity.<level>  the syslog severity of the level, e.g.
+//	              "severity.trace": "debug" or "severity.warn": "notice"
+//
+// The levels are sent with the severities debug, info, warning, err
+// and crit by default, following their order.
+type SinkConfig struct {
+	Name     string            // for the errors, optional
+	Type     string            // "stderr", "stdout", "file" or "syslog"
+	MinLevel string            // the lowest level written, all if empty
+	MaxLevel string            // the highest level written, all if empty
+	Format   string            // "text", "json" or "logfmt"
+	Options  map[string]string // type specific, see above
+}
+
+var sinkOptions = map[string][]string{
+	"stderr": {"ansi_color"},
+	"stdout": {"ansi_color"},
+	"file":   {"location", "limit_size", "backup_count", "compress", "period"},
+	"syslog": {"network", "address", "facility", "tag"},
+}
+
+const severityOption = "severity."
+
+// syslogFacilities and syslogSeverities are the values of
+// syslog.Priority by name.
+var (
+	syslogFacilities = map[string]int{
+		"kern": 0 << 3, "user": 1 << 3, "mail": 2 << 3, "daemon": 3 << 3,
+		"auth": 4 << 3, "syslog": 5 << 3, "lpr": 6 << 3, "news": 7 << 3,
+		"uucp": 8 << 3, "cron": 9 << 3, "authpriv": 10 << 3, "ftp": 11 << 3,
+		"local0": 16 << 3, "local1": 17 << 3, "local2": 18 << 3, "local3": 19 << 3,
+		"local4": 20 << 3, "local5": 21 << 3, "local6": 22 << 3, "local7": 23 << 3,
+	}
+	syslogSeverities = map[string]int{
+		"emerg": 0, "alert": 1, "crit": 2, "err": 3, "error": 3,
+		"warning": 4, "warn": 4, "notice": 5, "info": 6, "debug": 7,
+	}
+)
+
+// syslogSeverity returns the default syslog severity of the level.
+func syslogSeverity(level string) int {
+	switch n := severity(level); {
+	case n >= severity(multi.Lfatal):
+		return syslogSeverities["crit"]
+	case n >= severity(multi.Lerror):
+		return syslogSeverities["err"]
+	case n >= severity(multi.Lwarn):
+		return syslogSeverities["warning"]
+	case n >= severity(multi.Linfo):
+		return syslogSeverities["info"]
+	}
+	return syslogSeverities["debug"]
+}
+
+// syslogSeverityMap returns the syslog severities of the levels in
+// the options.
+func syslogSeverityMap(opts map[string]string) map[string]int {
+	m := make(map[string]int)
+	for key, value := range opts {
+		name, ok := strings.CutPrefix(key, severityOption)
+		if !ok {
+			continue
+		}
+		level, ok := multi.ParseLevel(name)
+		sev, known := syslogSeverities[strings.ToLower(value)]
+		if ok && known {
+			m[level] = sev
+		}
+	}
+	return m
+}
+
+// The kinds of the sinks of the console and the file of Config, which
+// have their levels in ModuleConfig.
+const (
+	consoleKind = iota + 1
+	fileKind
+)
+
+// sink is an output of the loggers for a range of levels.
+type sink struct {
+	min, max int32 // the severities of the levels written
+	out      multi.Outputter
+	w        io.Writer  // for StdLogAt
+	ansi     bool       // w is a console colored by level
+	fw       *file.File // nil if none
+	closer   io.Closer  // closed with the sinks, nil if none
+	kind     int        // consoleKind and fileKind of Config, or 0
+	name     string     // SinkConfig.Name, for ModuleConfig.SinkLevels
+
+	// levelW returns the writer of the level for StdLogAt, if not nil
+	levelW func(level string) io.Writer
+}
+
+// writer returns the writer of the level for StdLogAt.
+func (s *sink) writer(level string) io.Writer {
+	switch {
+	case s.levelW != nil:
+		return s.levelW(level)
+	case s.ansi:
+		return &ansiTerm{w: s.w, color: levelColor(level)}
+	}
+	return s.w
+}
+
+func (s *sink) has(n int32) bool {
+	return s.min <= n && n <= s.max
+}
+
+// levelRange returns the severities of the levels, the lowest and the
+// highest ones if empty.
+func levelRange(min, max string) (int32, int32) {
+	lo, hi := int32(math.MinInt32), int32(math.MaxInt32)
+	if min != "" {
+		lo = severity(ltos(min))
+	}
+	if max != "" {
+		hi = severity(ltos(max))
+	}
+	return lo, hi
+}
+
+func consoleSink(w io.Writer, min, max int32, format string, ansi bool) sink {
+	f := newFormatter(format)
+	if ansi {
+		f = ansiFormatter{f}
+	}
+	return sink{min: min, max: max, out: multi.NewWriter(w, f), w: w, ansi: ansi}
+}
+
+func fileSink(fw *file.File, min, max int32, format string) sink {
+	return sink{min: min, max: max, out: multi.NewWriter(fw, newFormatter(format)), w: fw, fw: fw, closer: fw}
+}
+
+// newSink creates the sink of the config. The files of the old sinks
+// are reused by location.
+func newSink(sc *SinkConfig, files map[string]*file.File) (sink, error) {
+	min, max := levelRange(sc.MinLevel, sc.MaxLevel)
+	ansi, _ := strconv.ParseBool(sc.Options["ansi_color"])
+	switch strings.ToLower(sc.Type) {
+	case "stderr":
+		return consoleSink(os.Stderr, min, max, sc.Format, ansi), nil
+	case "stdout":
+		return consoleSink(os.Stdout, min, max, sc.Format, ansi), nil
+	case "file":
+		fw, err := openSinkFile(sc.Options, files)
+		if err != nil {
+			return sink{}, err
+		}
+		return fileSink(fw, min, max, sc.Format), nil
+	case "syslog":
+		return syslogSink(sc, min, max)
+	}
+	return sink{}, errors.New("unknown type " + strconv.Quote(sc.Type))
+}
+
+func openSinkFile(opts map[string]string, files map[string]*file.File) (*file.File, error) {
+	d := Default()
+	var fo fileOptions
+	fo.limitSize, _ = strToNumSuffix(option(opts, "limit_size", d.FileLimitSize), 1024)
+	fo.backupFiles, _ = strconv.Atoi(option(opts, "backup_count", strconv.Itoa(d.FileBackupCount)))
+	fo.compress, _ = strconv.ParseBool(opts["compress"])
+	fo.period, _ = parsePeriod(opts["period"])
+	return openFile(opts["location"], fo, files)
+}
+
+// fileOptions are the settings of a file, applied again when the file
+// is reused by Reconfigure.
+type fileOptions struct {
+	limitSize   int64
+	backupFiles int
+	compress    bool
+	period      file.Period
+}
+
+// openFile opens the file at location, or returns the one in files,
+// with the options.
+func openFile(location string, fo fileOptions, files map[string]*file.File) (*file.File, error) {
+	fw, ok := files[location]
+	if ok {
+		fw.SetLimits(fo.limitSize, fo.backupFiles)
+	} else {
+		var err error
+		if fw, err = file.OpenFile(location, fo.limitSize, fo.backupFiles); err != nil {
+			return nil, err
+		}
+		files[location] = fw
+	}
+	fw.Configure(func(f *file.File) {
+		f.Compress = fo.compress
+		f.Period = fo.period
+	})
+	return fw, nil
+}
+
+func option(opts map[string]string, key, def string) string {
+	if v, ok := opts[key]; ok {
+		return v
+	}
+	return def
+}
+
+func parsePeriod(s string) (file.Period, bool) {
+	switch strings.ToLower(s) {
+	case "":
+		return file.NoPeriod, true
+	case "hourly":
+		return file.Hourly, true
+	case "daily":
+		return file.Daily, true
+	}
+	return file.NoPeriod, false
+}
+
+// validate returns the errors of the bad fields of the sink.
+func (sc *SinkConfig) validate() (errs []error) {
+	bad := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	keys, ok := sinkOptions[strings.ToLower(sc.Type)]
+	if !ok {
+		bad("unknown type %q", sc.Type)
+	}
+	if !isLevel(sc.MinLevel) {
+		bad("MinLevel: unknown level %q", sc.MinLevel)
+	}
+	if !isLevel(sc.MaxLevel) {
+		bad("MaxLevel: unknown level %q", sc.MaxLevel)
+	}
+	if min, max := levelRange(sc.MinLevel, sc.MaxLevel); min > max {
+		bad("MinLevel %q is above MaxLevel %q", sc.MinLevel, sc.MaxLevel)
+	}
+	if !isFormat(sc.Format) {
+		bad("Format: unknown format %q", sc.Format)
+	}
+
+	for key, value := range sc.Options {
+		known := false
+		for _, k := range keys {
+			known = known || k == key
+		}
+		if name, found := strings.CutPrefix(key, severityOption); found && strings.ToLower(sc.Type) == "syslog" {
+			if !isLevel(name) || name == "" {
+				bad("Options: %s: unknown level %q", key, name)
+			}
+			if _, ok := syslogSeverities[strings.ToLower(value)]; !ok {
+				bad("Options: %s: unknown severity %q", key, value)
+			}
+			continue
+		}
+		if !known && ok {
+			bad("Options: unknown option %q", key)
+			continue
+		}
+		var err error
+		switch key {
+		case "ansi_color", "compress":
+			_, err = strconv.ParseBool(value)
+		case "limit_size":
+			_, err = strToNumSuffix(value, 1024)
+		case "backup_count":
+			_, err = strconv.Atoi(value)
+		case "period":
+			if _, ok := parsePeriod(value); !ok {
+				err = errors.New("unknown period")
+			}
+		case "facility":
+			if _, ok := syslogFacilities[strings.ToLower(value)]; !ok {
+				err = errors.New("unknown facility")
+			}
+		}
+		if err != nil {
+			bad("Options: %s: bad value %q", key, value)
+		}
+	}
+	if strings.ToLower(sc.Type) == "file" && sc.Options["location"] == "" {
+		bad("Options: location is required")
+	}
+	return
+}
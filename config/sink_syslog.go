@@ -0,0 +1,92 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+//go:build !plan9
+
+package config
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ccpaging/log/multi"
+	"github.com/ccpaging/log/syslog"
+)
+
+// syslogOutput writes the records with the syslog severities of their
+// levels. The time is added by syslog.
+type syslogOutput struct {
+	w   *syslog.Writer
+	f   multi.Formatter
+	sev map[string]int // the severities set by the options
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// syslogSink creates the sink of the syslog daemon, which connects on
+// the first write and reconnects after errors, so a daemon down at
+// startup is written to once it is up.
+func syslogSink(sc *SinkConfig, min, max int32) (sink, error) {
+	name := option(sc.Options, "facility", "user")
+	facility, ok := syslogFacilities[strings.ToLower(name)]
+	if !ok {
+		return sink{}, errors.New("unknown facility " + strconv.Quote(name))
+	}
+	w, err := syslog.NewWriter(sc.Options["network"], sc.Options["address"],
+		syslog.Priority(facility)|syslog.LOG_INFO, sc.Options["tag"])
+	if err != nil {
+		return sink{}, err
+	}
+
+	var f multi.Formatter = &multi.TextFormatter{}
+	if !strings.EqualFold(sc.Format, "text") && sc.Format != "" {
+		f = newFormatter(sc.Format)
+	}
+	o := &syslogOutput{w: w, f: f, sev: syslogSeverityMap(sc.Options)}
+	return sink{
+		min:    min,
+		max:    max,
+		out:    o,
+		w:      w,
+		closer: w,
+		levelW: func(level string) io.Writer {
+			return priorityWriter{w, o.priority(level)}
+		},
+	}, nil
+}
+
+// priority returns the syslog severity of the level.
+func (o *syslogOutput) priority(level string) syslog.Priority {
+	if sev, ok := o.sev[level]; ok {
+		return syslog.Priority(sev)
+	}
+	return syslog.Priority(syslogSeverity(level))
+}
+
+func (o *syslogOutput) Output(calldepth int, s string) error {
+	_, err := o.w.Write([]byte(s))
+	return err
+}
+
+func (o *syslogOutput) OutputRecord(calldepth int, r *multi.Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.buf = o.f.Format(o.buf[:0], r)
+	_, err := o.w.WritePriority(o.priority(r.Level), string(o.buf))
+	return err
+}
+
+// priorityWriter writes to syslog with a fixed severity.
+type priorityWriter struct {
+	w *syslog.Writer
+	p syslog.Priority
+}
+
+func (w priorityWriter) Write(b []byte) (int, error) {
+	return w.w.WritePriority(w.p, string(b))
+}
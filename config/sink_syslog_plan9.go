@@ -0,0 +1,12 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+//go:build plan9
+
+package config
+
+import "errors"
+
+func syslogSink(sc *SinkConfig, min, max int32) (sink, error) {
+	return sink{}, errors.New("syslog is not supported")
+}
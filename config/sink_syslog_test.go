@@ -0,0 +1,155 @@
+//go:build !plan9
+
+package config_test
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ccpaging/log/config"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	c := &config.Config{Sinks: []config.SinkConfig{{
+		Type:     "syslog",
+		MinLevel: "info",
+		Options: map[string]string{
+			"network":       "udp",
+			"address":       conn.LocalAddr().String(),
+			"facility":      "local0",
+			"tag":           "test",
+			"severity.warn": "notice",
+		},
+	}}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b := config.NewBuilder(c)
+	defer b.Close()
+
+	logger := b.Logger("test: ")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	b.StdLogAt("fatal", "std: ").Print("fatal")
+
+	const local0 = 16 << 3
+	for _, want := range []struct {
+		pri int
+		msg string
+	}{
+		{local0 | 6, "INFO test: info"},
+		{local0 | 5, "WARN test: warn"},
+		{local0 | 3, "ERROR test: error"},
+		{local0 | 2, "fatal"},
+	} {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, fmt.Sprintf("<%d>", want.pri)) || !strings.Contains(got, want.msg) {
+			t.Errorf("got %q, want <%d> and %q", got, want.pri, want.msg)
+		}
+	}
+}
+
+func TestSyslogSinkValidate(t *testing.T) {
+	c := config.Default()
+	c.Sinks = []config.SinkConfig{{Type: "syslog", Options: map[string]string{
+		"facility":      "local9",
+		"severity.loud": "info",
+		"severity.info": "whisper",
+	}}}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{"facility", `unknown level "loud"`, `unknown severity "whisper"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s is not reported: %v", want, err)
+		}
+	}
+}
+
+func TestSyslogSinkReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// the daemon is down at startup
+	c := &config.Config{Sinks: []config.SinkConfig{{
+		Type:    "syslog",
+		Options: map[string]string{"network": "tcp", "address": addr, "tag": "test"},
+	}}}
+	b := config.NewBuilder(c)
+	defer b.Close()
+	logger := b.Logger("test: ")
+	logger.Info("lost")
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	logger.Info("delayed")
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf[:n])
+	if !strings.Contains(got, "INFO test: delayed") {
+		t.Errorf("got %q, want the record written after the daemon is up", got)
+	}
+	if strings.Contains(got, "lost") {
+		t.Errorf("got %q, want the record written while the daemon is down dropped", got)
+	}
+}
+
+func TestSyslogSinkFacility(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	// not validated, the sink is not created instead of using kern
+	b := config.NewBuilder(&config.Config{Sinks: []config.SinkConfig{{
+		Type: "syslog",
+		Options: map[string]string{
+			"network":  "udp",
+			"address":  conn.LocalAddr().String(),
+			"facility": "local9",
+		},
+	}}})
+	defer b.Close()
+	b.Logger("test: ").Error("error")
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	buf := make([]byte, 1024)
+	if n, _, err := conn.ReadFrom(buf); err == nil {
+		t.Errorf("got %q from the sink with an unknown facility", buf[:n])
+	}
+}
@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/log/config"
+)
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	errorsLog, debugLog := filepath.Join(dir, "errors.log"), filepath.Join(dir, "debug.log")
+	c := &config.Config{Sinks: []config.SinkConfig{
+		{Type: "file", MinLevel: "error", Format: "json", Options: map[string]string{"location": errorsLog}},
+		{Type: "file", MinLevel: "debug", MaxLevel: "info", Options: map[string]string{"location": debugLog, "compress": "true"}},
+	}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b := config.NewBuilder(c)
+	logger := b.Logger("test: ")
+	logger.Trace("trace")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	b.StdLogAt("error", "std: ").Print("std")
+	b.Close()
+
+	if got := mustRead(t, errorsLog); !strings.Contains(got, `"msg":"error"`) ||
+		!strings.Contains(got, "ERROR std: ") || strings.Count(got, "\n") != 2 {
+		t.Errorf("errors: %q", got)
+	}
+	if got := mustRead(t, debugLog); !strings.Contains(got, "DEBG test: debug") ||
+		!strings.Contains(got, "INFO test: info") || strings.Count(got, "\n") != 2 {
+		t.Errorf("debug: %q", got)
+	}
+}
+
+func TestSinksValidate(t *testing.T) {
+	c := config.Default()
+	c.Sinks = []config.SinkConfig{
+		{Name: "bad", Type: "pigeon"},
+		{Type: "file", MinLevel: "error", MaxLevel: "debug", Format: "xml",
+			Options: map[string]string{"compress": "maybe", "color": "red"}},
+	}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{
+		`Sinks[0] "bad": unknown type "pigeon"`,
+		"Sinks[1]: MinLevel",
+		"Sinks[1]: Format",
+		"Sinks[1]: Options: compress",
+		`Sinks[1]: Options: unknown option "color"`,
+		"Sinks[1]: Options: location is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s is not reported: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ccpaging/log/file"
+)
+
+var DefaultWatchInterval = 5 * time.Second
+
+// Watcher reloads the config file into a builder when the file changes,
+// or when the process receives SIGHUP.
+type Watcher struct {
+	Interval time.Duration   // polling interval, no polling if <= 0
+	SIGHUP   bool            // reload on SIGHUP, if the system has it
+	Prefix   string          // the prefix of the environment, see ApplyEnv
+	OnError  func(err error) // called when reloading fails
+
+	b    *Builder
+	path string
+
+	mu   sync.Mutex
+	stat os.FileInfo // the file when last loaded
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher creates a watcher of the config file at path. Set the
+// fields, then call Start.
+func NewWatcher(b *Builder, path string) *Watcher {
+	return &Watcher{
+		Interval: DefaultWatchInterval,
+		b:        b,
+		path:     path,
+	}
+}
+
+// Start watches the file in the background, until Stop.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stop != nil {
+		return
+	}
+	w.stat, _ = os.Stat(w.path)
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.run(w.stop, w.done)
+}
+
+func (w *Watcher) run(stop, done chan struct{}) {
+	defer close(done)
+
+	var tick <-chan time.Time
+	if w.Interval > 0 {
+		t := time.NewTicker(w.Interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	hup := make(chan os.Signal, 1)
+	if w.SIGHUP && file.NotifySIGHUP(hup) {
+		defer signal.Stop(hup)
+	}
+
+	for {
+		var err error
+		select {
+		case <-stop:
+			return
+		case <-tick:
+			if w.changed() {
+				err = w.Reload()
+			}
+		case <-hup:
+			err = w.Reload()
+		}
+		if err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	}
+}
+
+// Stop stops watching the file.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// changed reports whether the file is modified since last loaded.
+func (w *Watcher) changed() bool {
+	stat, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stat == nil || !stat.ModTime().Equal(w.stat.ModTime()) || stat.Size() != w.stat.Size()
+}
+
+// Reload loads the file and the environment, and reconfigures the
+// builder if the config differs from the current one. A bad config is
+// reported, and the current one is kept.
+func (w *Watcher) Reload() error {
+	stat, _ := os.Stat(w.path)
+	w.mu.Lock()
+	w.stat = stat
+	w.mu.Unlock()
+
+	c, err := Load(w.path)
+	if err == nil && w.Prefix != "" {
+		err = c.ApplyEnv(w.Prefix)
+	}
+	if err != nil {
+		return err
+	}
+	if current := w.b.Config(); reflect.DeepEqual(&current, c) {
+		return nil
+	}
+	return w.b.Reconfigure(c)
+}
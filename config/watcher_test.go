@@ -0,0 +1,199 @@
+package config_test
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ccpaging/log/config"
+	"github.com/ccpaging/log/multi"
+)
+
+func countLines(t *testing.T, paths ...string) (n int) {
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		n += strings.Count(string(contents), "\n")
+	}
+	return
+}
+
+func TestReconfigure(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	c := &config.Config{EnableFile: true, FileLevel: "info", FileLocation: a, FileLimitSize: "10M"}
+	builder := config.NewBuilder(c)
+	logger := builder.Logger("test: ")
+	defer logger.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				logger.Info("line")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		c := *c
+		if i%2 == 0 {
+			c.FileLocation = b
+		}
+		if err := builder.Reconfigure(&c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	builder.Close()
+
+	if n := countLines(t, a, b); n != 2000 {
+		t.Errorf("got %d lines, want 2000", n)
+	}
+
+	// the level of the file
+	c.FileLevel = "debug"
+	builder.Reconfigure(c)
+	logger.Debug("debug")
+	builder.Close()
+	if n := countLines(t, a); n == 0 || !strings.HasSuffix(mustRead(t, a), "debug\n") {
+		t.Errorf("debug is not written: %q", mustRead(t, a))
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	c := &config.Config{EnableConsole: true, ConsoleLevel: "error"}
+	builder := config.NewBuilder(c)
+	defer builder.Close()
+
+	logger := builder.Logger("test: ")
+	if logger.Enabled(multi.Ldebug) || logger.Level() != multi.Lerror {
+		t.Errorf("level: %q", logger.Level())
+	}
+	c.ConsoleLevel = "debug"
+	builder.Reconfigure(c)
+	if !logger.Enabled(multi.Ldebug) || logger.Enabled(multi.Ltrace) {
+		t.Errorf("reconfigured level: %q", logger.Level())
+	}
+	c.EnableConsole = false
+	builder.Reconfigure(c)
+	if logger.Enabled(multi.Lerror) {
+		t.Errorf("no sinks: %q", logger.Level())
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.json")
+	write := func(location, level string) {
+		os.WriteFile(path, []byte(fmt.Sprintf(`{"EnableConsole": false, "EnableFile": true,
+			"FileLocation": %q, "FileLevel": %q}`, location, level)), 0644)
+	}
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	write(a, "info")
+
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := config.NewBuilder(c)
+	defer builder.Close()
+
+	w := config.NewWatcher(builder, path)
+	w.Interval = 10 * time.Millisecond
+	var errs []error
+	var mu sync.Mutex
+	w.OnError = func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	w.Start()
+	defer w.Stop()
+
+	// bad configs are reported and ignored
+	write(a, "loud")
+	for i := 0; ; i++ {
+		mu.Lock()
+		n := len(errs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if i == 200 {
+			t.Fatal("bad config is not reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	write(b, "error")
+	for i := 0; builder.Config().FileLocation != b; i++ {
+		if i == 200 {
+			t.Fatal("config is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if level := builder.Config().FileLevel; level != "error" {
+		t.Errorf("FileLevel: %q", level)
+	}
+}
+
+func TestReconfigureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	sc := config.SinkConfig{Type: "file", Format: "text", Options: map[string]string{
+		"location": path, "limit_size": "1k", "backup_count": "1",
+	}}
+	c := &config.Config{Sinks: []config.SinkConfig{sc}}
+	builder := config.NewBuilder(c)
+	logger := builder.Logger("test: ")
+	logger.Info("text")
+
+	// the file is reused with the new format and compression
+	sc.Format = "json"
+	sc.Options = map[string]string{"location": path, "limit_size": "1k", "backup_count": "1", "compress": "true"}
+	c.Sinks = []config.SinkConfig{sc}
+	if err := builder.Reconfigure(c); err != nil {
+		t.Fatal(err)
+	}
+	backup := strings.TrimSuffix(path, ".log") + ".1.log"
+	rolled := func() bool {
+		_, err := os.Stat(backup)
+		_, gzErr := os.Stat(backup + ".gz")
+		return err == nil || gzErr == nil
+	}
+	for i := 0; i < 100 && !rolled(); i++ {
+		logger.Info("json")
+	}
+	builder.Close()
+
+	f, err := os.Open(backup + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, _ := io.ReadAll(r)
+	if !strings.Contains(string(contents), "INFO test: text\n") || !strings.Contains(string(contents), `"msg":"json"`) {
+		t.Errorf("backup: %q", contents)
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import "os"
+
+func chown(file *os.File, uid, gid int) error {
+	return file.Chown(uid, gid)
+}
@@ -0,0 +1,12 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+//go:build !linux
+
+package file
+
+import "os"
+
+// The owner of the file is not changed on this platform.
+func chown(file *os.File, uid, gid int) error {
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+const (
+	gzipExt = ".gz"
+	tmpExt  = ".tmp"
+)
+
+// backup returns the path of the backup file, compressed or not, or the
+// empty string if the backup file does not exist.
+func backup(slot string) string {
+	if _, err := os.Stat(slot); err == nil {
+		return slot
+	}
+	if _, err := os.Stat(slot + gzipExt); err == nil {
+		return slot + gzipExt
+	}
+	return ""
+}
+
+// cleanup repairs the backup file left by a process exiting during the
+// compression. The compressed file is complete once renamed, so the
+// source is removed if both exist. It reports whether the source still
+// needs to be compressed.
+func cleanup(slot string) bool {
+	os.Remove(slot + gzipExt + tmpExt)
+	if _, err := os.Stat(slot); err != nil {
+		return false
+	}
+	if _, err := os.Stat(slot + gzipExt); err == nil {
+		os.Remove(slot)
+		return false
+	}
+	return true
+}
+
+// compress writes the gzip file of src, then removes src. The temporary
+// file is renamed when complete, so a gzip file is never truncated.
+func compress(src string, mode os.FileMode) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := src + gzipExt + tmpExt
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, src+gzipExt); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,296 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Failure is the policy of the file when writing fails, e.g. when the
+// disk is full.
+type Failure int
+
+const (
+	// ReturnError returns the error to the writer. The buffered data
+	// failing to be flushed is discarded, counted by Dropped.
+	ReturnError Failure = iota
+	// FallbackStderr writes the data to Fallback, os.Stderr if nil.
+	FallbackStderr
+	// Drop discards the data, counted by Dropped.
+	Drop
+	// Retry keeps the data, up to MaxRetrySize bytes, and writes it
+	// again in the background after RetryInterval, doubled every retry.
+	// The data is discarded like Drop after Retries. A write exceeding
+	// MaxRetrySize is discarded at once, and returns ErrSuspended.
+	Retry
+)
+
+var (
+	DefaultRetries       = 3
+	DefaultRetryInterval = 100 * time.Millisecond
+
+	// MaxRetryInterval limits the interval between the attempts of a
+	// failing file to write again.
+	MaxRetryInterval = time.Minute
+
+	// MaxRetrySize limits the data kept by Retry, the data exceeding it
+	// is dropped.
+	MaxRetrySize = 1 << 20
+)
+
+// ErrSuspended is returned while a failing file waits to write again.
+var ErrSuspended = errors.New("file: writing is suspended after an error")
+
+// unflushed is the writer of the buffer, which keeps the data failing
+// to be flushed in f.lost for the failure policy, since the writers
+// were told it is written. The writes are tracked by the offsets where
+// they end in the data passed through the buffer.
+type unflushed struct {
+	f *File
+	w io.Writer
+}
+
+func (u unflushed) Write(p []byte) (n int, err error) {
+	f := u.f
+	n, err = u.w.Write(p)
+	f.flushed += int64(n)
+
+	i := 0
+	for i < len(f.ends) && f.ends[i] <= f.flushed {
+		i++
+	}
+	if err != nil {
+		// the writes not flushed entirely
+		f.lost = append(f.lost, p[n:]...)
+		f.lostEnd = f.flushed + int64(len(p)-n)
+		f.lostN += len(f.ends) - i
+		i = len(f.ends)
+	}
+	f.ends = f.ends[i:]
+	return
+}
+
+// returned removes the data after the offset from f.lost. The buffer
+// writes it directly if the buffer is empty, and the writer is told it
+// is not written. It must be called with f.mu held.
+func (f *File) returned(offset int64) {
+	if over := f.lostEnd - offset; over > 0 {
+		f.lost = f.lost[:len(f.lost)-int(min(over, int64(len(f.lost))))]
+		f.lostEnd = offset
+	}
+}
+
+// fail discards the file and the buffer after the error, and schedules
+// the next attempt to write. It must be called with f.mu held.
+func (f *File) fail(err error) {
+	f.errs = append(f.errs, err)
+
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = nil
+	f.bufWriter = nil
+	f.ends, f.flushed = nil, 0
+	f.size = 0
+
+	switch {
+	case f.backoff <= 0:
+		f.backoff = f.RetryInterval
+		if f.backoff <= 0 {
+			f.backoff = DefaultRetryInterval
+		}
+	case f.backoff < MaxRetryInterval:
+		f.backoff *= 2
+		if f.backoff > MaxRetryInterval {
+			f.backoff = MaxRetryInterval
+		}
+	}
+	f.retryAt = time.Now().Add(f.backoff)
+}
+
+// recovered resets the schedule of the attempts after writing again.
+func (f *File) recovered() {
+	f.backoff = 0
+	f.retryAt = time.Time{}
+	f.attempts = 0
+}
+
+// try writes b unless the file waits to write again after an error, or
+// Retry keeps data to write at first.
+func (f *File) try(level string, b []byte) (n int, err error) {
+	if f.Failure != ReturnError && (time.Now().Before(f.retryAt) || len(f.backlog) > 0) {
+		return 0, ErrSuspended
+	}
+	if n, err = f.writeRolling(b); err == nil && f.isSevere(level) {
+		err = f.flush()
+	}
+	if err != nil {
+		f.fail(err)
+		return
+	}
+	f.recovered()
+	return
+}
+
+func (f *File) retries() int {
+	if f.Retries <= 0 {
+		return DefaultRetries
+	}
+	return f.Retries
+}
+
+// keep queues the data of n writes to be written again by Retry, or
+// drops it if the queue would exceed MaxRetrySize. It reports whether
+// the data is kept. It must be called with f.mu held.
+func (f *File) keep(p []byte, n int) bool {
+	if len(p) == 0 {
+		return true
+	}
+	if len(f.backlog)+len(p) > MaxRetrySize {
+		f.dropped.Add(uint64(n))
+		return false
+	}
+	f.backlog = append(f.backlog, p...)
+	f.backlogN += n
+	if f.retrying == nil {
+		f.retrying = time.AfterFunc(time.Until(f.retryAt), f.retry)
+	}
+	return true
+}
+
+// retry writes the data kept by Retry in the background.
+func (f *File) retry() {
+	f.mu.Lock()
+	f.retrying = nil
+	if len(f.backlog) == 0 {
+		// closed
+		f.mu.Unlock()
+		return
+	}
+	f.writeBacklog(false)
+	s := f.settle(true)
+	f.mu.Unlock()
+
+	s.apply(f)
+}
+
+// writeBacklog writes the data kept by Retry. If it fails, the data is
+// kept for the next attempt, or dropped after Retries or if it is the
+// last attempt. It must be called with f.mu held.
+func (f *File) writeBacklog(last bool) {
+	backlog, n := f.backlog, f.backlogN
+	f.backlog, f.backlogN = nil, 0
+
+	m, err := f.writeRolling(backlog)
+	if err == nil {
+		err = f.flush()
+	}
+	if err == nil {
+		f.recovered()
+		return
+	}
+
+	f.fail(err)
+	rest := append(f.lost, backlog[m:]...)
+	f.lost, f.lostN = nil, 0
+	if f.attempts++; last || f.attempts >= f.retries() {
+		f.attempts = 0
+		f.dropped.Add(uint64(n))
+		return
+	}
+	f.keep(rest, n)
+}
+
+// failures are the errors and the lost data to be handled without f.mu
+// held.
+type failures struct {
+	errs     []error
+	onError  func(err error)
+	lost     []byte
+	lostN    int
+	policy   Failure
+	fallback io.Writer
+}
+
+// settle takes the errors and the data lost since the last call. The
+// lost data is kept if the policy is Retry and keep is true. It must
+// be called with f.mu held.
+func (f *File) settle(keep bool) failures {
+	s := failures{
+		errs:     f.errs,
+		onError:  f.OnError,
+		lost:     f.lost,
+		lostN:    f.lostN,
+		policy:   f.Failure,
+		fallback: f.Fallback,
+	}
+	f.errs, f.lost, f.lostN = nil, nil, 0
+	if s.fallback == nil {
+		s.fallback = os.Stderr
+	}
+	if s.policy == Retry && keep {
+		f.keep(s.lost, s.lostN)
+		s.lost, s.lostN = nil, 0
+	}
+	return s
+}
+
+// apply calls OnError with the errors, and applies the failure policy
+// to the lost data. It must be called without f.mu held.
+func (s *failures) apply(f *File) {
+	if s.onError != nil {
+		for _, err := range s.errs {
+			s.onError(err)
+		}
+	}
+	if len(s.lost) == 0 {
+		return
+	}
+	if s.policy == FallbackStderr {
+		s.fallback.Write(s.lost)
+		return
+	}
+	f.dropped.Add(uint64(s.lostN))
+}
+
+// writeLevel writes b, and applies the failure policy if it fails.
+func (f *File) writeLevel(level string, b []byte) (n int, err error) {
+	f.mu.Lock()
+	n, err = f.try(level, b)
+	s := f.settle(true)
+	if err != nil && s.policy == Retry {
+		if f.keep(b[n:], 1) {
+			n, err = len(b), nil
+		} else {
+			err = ErrSuspended
+		}
+	}
+	f.mu.Unlock()
+
+	s.apply(f)
+	if err == nil {
+		return
+	}
+
+	switch s.policy {
+	case FallbackStderr:
+		m, err := s.fallback.Write(b[n:])
+		return n + m, err
+	case Drop:
+		f.dropped.Add(1)
+		return len(b), nil
+	}
+	return
+}
+
+// Dropped returns the number of writes discarded by the failure policy.
+func (f *File) Dropped() uint64 {
+	return f.dropped.Load()
+}
@@ -31,10 +31,15 @@ package file
 
 import (
 	"bufio"
-	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ccpaging/log/multi"
 )
 
 var (
@@ -55,20 +60,125 @@ var (
 	DefaultLimitSize int64 = 1024 * 1024
 
 	DefaultBufferSize = 2 * os.Getpagesize()
+
+	DefaultFlushInterval = time.Second
 )
 
 // File represents the buffered writer, and rolling up automatic.
+//
+// The file is rolled when its size exceeds LimitSize, or when the
+// Period is over, whichever comes first. The periods begin in the time
+// zone Location, time.Local if nil.
+//
+// If Compress is set, the backup files are compressed with gzip in the
+// background and named like "name.1.ext.gz". The files left by a
+// process exiting during the compression are repaired at the next
+// rolling.
+//
+// The backup files older than MaxAge days, and the oldest backup files
+// exceeding MaxTotalSize bytes in total, are removed after rolling and
+// when the file is opened first.
+//
+// If TimeFormat is set, e.g. DefaultTimeFormat, the backup files are
+// named with the time of rolling like "name-2006-01-02T15-04-05.ext"
+// instead of shifting the numbered ones. If Symlink is set, it is a
+// symbolic link to the file.
+//
+// The buffer is flushed when it is full, every FlushInterval, and at
+// once after the records at or above FlushLevel written by WriteLevel.
+// If FlushInterval <= 0, the buffer is flushed after every write.
+//
+// Every CheckInterval, the path is compared with the open file, which
+// is reopened if it is moved or removed, e.g. by logrotate.
+//
+// If ProcessLock is set, the processes writing to the same path hold
+// an advisory lock on "path.lock" while writing and rolling, so every
+// record is appended with a single write, and the file is rolled by
+// one of them only. The others reopen the new file. It is supported on
+// the platforms with flock, and ignored on the others.
+//
+// OnPreRotate is called before rolling, and OnPostRotate after rolling
+// with the final path of the backup file, e.g. compressed. They are
+// called without blocking the writers. In ProcessLock mode, the file
+// may be rolled by another process after OnPreRotate, then OnPostRotate
+// is not called.
+//
+// If writing fails, e.g. when the disk is full, OnError is called, and
+// the data failing to be written, including the buffered data, is
+// handled by the policy. Unless Failure is ReturnError, the file then
+// waits before writing again, doubling the interval while failing, and
+// the data written meanwhile is handled by the policy too. Writing never
+// waits for the file.
 type File struct {
 	FilePath    string
 	FileMode    os.FileMode
+	Uid, Gid    int // the owner of the file, not changed if -1; Linux only
 	LimitSize   int64
 	BackupFiles int
+	Period      Period
+	Location    *time.Location
+	Compress    bool
+
+	MaxAge       int   // in days, no limit if <= 0
+	MaxTotalSize int64 // in bytes, no limit if <= 0
+
+	TimeFormat string // the time layout of the backup file names
+	Symlink    string // the path of a symbolic link to the file
+
+	mkdirAll bool
+	dirMode  os.FileMode
 
 	file *os.File
 	size int64
+	next time.Time // the end of the current period
+
+	retained bool           // retention enforced at the first open
+	pending  sync.WaitGroup // the background housekeeping
+	notified chan struct{}  // closed when the last event is delivered, nil if none
 
 	bufWriter  *bufio.Writer
 	Buffersize int
+
+	FlushInterval time.Duration
+	FlushLevel    string // e.g. "error", see multi.ParseLevel
+
+	flushLevel    string // FlushLevel when parsed
+	flushSeverity int32  // the severity of flushLevel
+	flushOK       bool   // flushLevel is a level
+
+	CheckInterval time.Duration // no check if <= 0
+	ProcessLock   bool
+
+	OnPreRotate  func(e RotateEvent) // called before rolling
+	OnPostRotate func(e RotateEvent) // called after rolling in background
+
+	mu      sync.Mutex
+	timer   *time.Timer // flushing the buffer, nil if not dirty
+	checked time.Time   // the last check of the path
+	lock    *os.File    // the lock file shared by the processes
+
+	rotating bool             // OnPreRotate is running
+	events   chan RotateEvent // nil if Events is not called
+
+	Failure       Failure
+	Fallback      io.Writer // for FallbackStderr, os.Stderr if nil
+	Retries       int       // for Retry, DefaultRetries if <= 0
+	RetryInterval time.Duration
+	OnError       func(err error)
+
+	errs     []error // to be reported by OnError
+	backoff  time.Duration
+	retryAt  time.Time // writing is suspended until then
+	ends     []int64   // the offsets where the buffered writes end
+	flushed  int64     // the offset of the data flushed by the buffer
+	lost     []byte    // the buffered data failing to be flushed
+	lostEnd  int64     // the offset where lost ends
+	lostN    int       // the writes in lost
+	backlog  []byte    // the data kept by Retry
+	backlogN int       // the writes in backlog
+	attempts int       // the failed attempts to write backlog
+	retrying *time.Timer
+	dropped  atomic.Uint64
 }
 
 // Open opens the named file for writing. If successful, methods on
@@ -82,14 +192,9 @@ func Open(filePath string) (*File, error) {
 // OpenFile is the generalized open call; most users will use Open
 // instead. It is created with mode perm (before umask) if necessary.
 // If successful, methods on the returned File can be used for io.Writer.
-func OpenFile(filePath string, limitSize int64, backupFiles int) (*File, error) {
-	path := filepath.Dir(filePath)
-	if stat, err := os.Stat(path); err != nil {
-		return nil, err
-	} else if !stat.IsDir() {
-		return nil, errors.New("Error: The file path " + filePath + ": is not a directory.")
-	}
-
+// The directory must exist unless WithMkdirAll is given, and be
+// writable.
+func OpenFile(filePath string, limitSize int64, backupFiles int, opts ...Option) (*File, error) {
 	if limitSize <= 0 {
 		limitSize = DefaultLimitSize
 	}
@@ -98,46 +203,112 @@ func OpenFile(filePath string, limitSize int64, backupFiles int) (*File, error)
 	}
 
 	f := &File{
-		FilePath:    filePath,
-		FileMode:    DefaultFileMode,
-		LimitSize:   limitSize,
-		BackupFiles: backupFiles,
-		Buffersize:  DefaultBufferSize,
+		FilePath:      filePath,
+		FileMode:      DefaultFileMode,
+		LimitSize:     limitSize,
+		BackupFiles:   backupFiles,
+		Buffersize:    DefaultBufferSize,
+		FlushInterval: DefaultFlushInterval,
+		CheckInterval: DefaultCheckInterval,
+		dirMode:       DefaultDirMode,
+		Uid:           -1,
+		Gid:           -1,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if err := f.prepare(); err != nil {
+		return nil, err
 	}
 	f.size = f.fileSize()
 	return f, nil
 }
 
 func (f *File) close() (err error) {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
 	if f.file != nil {
-		f.Flush()
+		if e := f.flush(); e != nil {
+			f.errs = append(f.errs, e)
+		}
 		err = f.file.Close()
 	}
 
 	f.size = 0
 	f.file = nil
 	f.bufWriter = nil
+	f.ends, f.flushed = nil, 0
 	return
 }
 
-// Close active buffered writer.
+// Close active buffered writer, and wait for the compression and the
+// retention of the backup files.
 func (f *File) Close() error {
-	return f.close()
+	unregister(f)
+
+	f.mu.Lock()
+	if f.retrying != nil {
+		f.retrying.Stop()
+		f.retrying = nil
+	}
+	if len(f.backlog) > 0 {
+		// the last attempt
+		f.writeBacklog(true)
+	}
+	err := f.close()
+	f.closeLock()
+	notified := f.notified
+	s := f.settle(false)
+	f.mu.Unlock()
+
+	s.apply(f)
+	f.pending.Wait()
+	if notified != nil {
+		<-notified
+	}
+
+	f.mu.Lock()
+	if f.events != nil && f.notified == notified {
+		// no event is sent since
+		close(f.events)
+		f.events = nil
+	}
+	f.mu.Unlock()
+	return err
 }
 
 func (f *File) open() error {
 	if f.file != nil {
 		return nil
 	}
+	if !f.retained {
+		f.retained = true
+		f.pending.Wait()
+		f.retain()
+	}
 	file, err := os.OpenFile(f.FilePath, DefaultFileFlag, f.FileMode)
 	if err != nil {
 		return err
 	}
+	if err := f.chown(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if f.Symlink != "" {
+		f.link()
+	}
 
 	f.file = file
 	f.bufWriter = nil
 	if f.Buffersize > 0 {
-		f.bufWriter = bufio.NewWriterSize(f.file, f.Buffersize)
+		var w io.Writer = f.file
+		if f.ProcessLock {
+			w = lockedWriter{f}
+		}
+		f.bufWriter = bufio.NewWriterSize(unflushed{f, w}, f.Buffersize)
 	}
 
 	f.size = 0
@@ -148,23 +319,87 @@ func (f *File) open() error {
 }
 
 func (f *File) write(b []byte) (n int, err error) {
-	if f.bufWriter != nil {
-		n, err = f.bufWriter.Write(b)
-	} else {
+	switch {
+	case f.bufWriter != nil:
+		if f.ProcessLock && f.bufWriter.Available() < len(b) {
+			// not splitting the record into two writes
+			f.flush()
+		}
+		start := f.flushed + int64(f.bufWriter.Buffered())
+		if n, err = f.bufWriter.Write(b); err != nil {
+			f.returned(start + int64(n))
+		} else {
+			f.ends = append(f.ends, start+int64(n))
+		}
+	case f.ProcessLock:
+		n, err = f.writeLocked(b)
+	default:
 		n, err = f.file.Write(b)
 	}
 
-	if err == nil {
-		f.size += int64(n)
-		f.Flush()
+	if err != nil {
+		return
+	}
+	f.size += int64(n)
+
+	switch {
+	case f.bufWriter == nil || f.bufWriter.Buffered() == 0:
+	case f.FlushInterval <= 0:
+		err = f.flush()
+	case f.timer == nil:
+		f.timer = time.AfterFunc(f.FlushInterval, f.tick)
 	}
 	return
 }
 
+func (f *File) tick() {
+	f.mu.Lock()
+	f.timer = nil
+	f.mu.Unlock()
+
+	f.Flush()
+}
+
 // Write bytes to file, and rolling up automatic.
 func (f *File) Write(b []byte) (n int, err error) {
-	if f.LimitSize > 0 && f.size > f.LimitSize {
-		f.rolling(f.BackupFiles)
+	return f.writeLevel("", b)
+}
+
+// WriteLevel writes the record of the level like Write, and flushes the
+// buffer at once if the level is at or above FlushLevel.
+func (f *File) WriteLevel(level string, b []byte) (n int, err error) {
+	return f.writeLevel(level, b)
+}
+
+// isSevere reports whether the level is at or above FlushLevel, which
+// is parsed again only when it is changed.
+func (f *File) isSevere(level string) bool {
+	if f.FlushLevel != f.flushLevel {
+		f.flushLevel, f.flushOK = f.FlushLevel, false
+		if flush, ok := multi.ParseLevel(f.FlushLevel); ok {
+			def, _ := multi.LookupLevel(flush)
+			f.flushSeverity, f.flushOK = def.Severity, true
+		}
+	}
+	if !f.flushOK {
+		return false
+	}
+	def, ok := multi.LookupLevel(level)
+	return ok && def.Severity >= f.flushSeverity
+}
+
+func (f *File) writeRolling(b []byte) (n int, err error) {
+	now := time.Now()
+	f.check(now)
+	if !f.rotating && !f.notifying() && (f.LimitSize > 0 && f.size > f.LimitSize || f.isTimeout(now)) {
+		if f.OnPreRotate != nil {
+			f.preRotate(now)
+		}
+		if f.ProcessLock {
+			f.rollingLocked(now)
+		} else {
+			f.rolling(f.BackupFiles, now)
+		}
 	}
 
 	if err := f.open(); err != nil {
@@ -174,57 +409,103 @@ func (f *File) Write(b []byte) (n int, err error) {
 	return f.write(b)
 }
 
-func (f *File) rolling(n int) {
+// rolling renames the file to the backup of the time now, which is the
+// time of the event, keeping n backup files.
+func (f *File) rolling(n int, now time.Time) {
 	f.close()
+	f.next = time.Time{}
+	e := &RotateEvent{Path: f.FilePath, Time: now}
 
 	if n < 1 {
 		// no backup file
 		os.Remove(f.FilePath)
+		f.housekeeping(nil, e)
 		return
 	}
 
 	ext := filepath.Ext(f.FilePath)                  // save extension like ".log"
 	name := f.FilePath[0 : len(f.FilePath)-len(ext)] // dir and name
 
+	if f.TimeFormat != "" {
+		f.rollingStamped(name, ext, n, e)
+		return
+	}
+
+	// the slots are shifted below, so wait for the last housekeeping
+	f.pending.Wait()
+
 	var (
 		i    int
-		err  error
 		slot string
+		last string
 	)
 
 	for i = 0; i < n; i++ {
-		// File name pattern is "name.<n>.ext"
+		// File name pattern is "name.<n>.ext", or "name.<n>.ext.gz"
 		slot = name + "." + strconv.Itoa(i+1) + ext
-		_, err = os.Stat(slot)
-		if err != nil {
+		cleanup(slot)
+		if last = backup(slot); last == "" {
 			break
 		}
 	}
-	if err == nil {
+	if last != "" {
 		// Too much backup files. Remove last one
-		os.Remove(slot)
+		os.Remove(last)
 		i--
 	}
 
 	for ; i > 0; i-- {
 		prev := name + "." + strconv.Itoa(i) + ext
-		os.Rename(prev, slot)
+		if path := backup(prev); path != "" {
+			os.Rename(path, slot+path[len(prev):])
+		}
 		slot = prev
 	}
 
-	os.Rename(f.FilePath, name+".1"+ext)
+	slot = name + ".1" + ext
+	if err := os.Rename(f.FilePath, slot); err != nil && !os.IsNotExist(err) {
+		f.errs = append(f.errs, err)
+	}
+	e.Backup = slot
+
+	var slots []string
+	if f.Compress {
+		for i = 1; i <= n; i++ {
+			if slot = name + "." + strconv.Itoa(i) + ext; cleanup(slot) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	f.housekeeping(slots, e)
 }
 
-func (f *File) Flush() (err error) {
-	if f.bufWriter != nil {
-		err = f.bufWriter.Flush()
-		return
+// Flush writes the buffered data to the file, or commits the file to
+// stable storage if it is not buffered. If it fails, the buffered data
+// is handled by the failure policy.
+func (f *File) Flush() error {
+	f.mu.Lock()
+	buffered := f.bufWriter != nil
+	err := f.flush()
+	if err != nil && buffered {
+		f.fail(err)
 	}
-	if f.file != nil {
+	if err == nil && !buffered && f.file != nil {
 		err = f.file.Sync()
-		return
 	}
-	return
+	s := f.settle(true)
+	f.mu.Unlock()
+
+	s.apply(f)
+	return err
+}
+
+// flush writes the buffered data to the file. It does not commit the
+// file to stable storage, see Flush.
+func (f *File) flush() error {
+	if f.bufWriter != nil {
+		return f.bufWriter.Flush()
+	}
+	return nil
 }
 
 func (f *File) fileSize() int64 {
@@ -237,3 +518,29 @@ func (f *File) fileSize() int64 {
 	}
 	return fi.Size()
 }
+
+// SetLimits changes LimitSize and BackupFiles like the arguments of
+// OpenFile, while the file is written.
+func (f *File) SetLimits(limitSize int64, backupFiles int) {
+	if limitSize <= 0 {
+		limitSize = DefaultLimitSize
+	}
+	if backupFiles < 0 {
+		backupFiles = 1
+	}
+
+	f.Configure(func(f *File) {
+		f.LimitSize = limitSize
+		f.BackupFiles = backupFiles
+	})
+}
+
+// Configure calls fn to change the fields while the file is written.
+func (f *File) Configure(fn func(f *File)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the housekeeping reads the fields
+	f.pending.Wait()
+	fn(f)
+}
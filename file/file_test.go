@@ -3,10 +3,20 @@
 package file
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/ccpaging/log/multi"
 )
 
 var testFiles []string = []string{"_test.log", "_test.1.log"}
@@ -56,6 +66,714 @@ func TestRolling(t *testing.T) {
 	}
 }
 
+func TestNextRolling(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2021, 12, 31, 23, 30, 0, 0, time.UTC) // 07:30 in loc
+
+	f := &File{Period: Hourly, Location: loc}
+	if got, want := f.nextRolling(now), time.Date(2022, 1, 1, 8, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("hourly: got %v, want %v", got, want)
+	}
+	f.Period = Daily
+	if got, want := f.nextRolling(now), time.Date(2022, 1, 2, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("daily: got %v, want %v", got, want)
+	}
+	f.Period = NoPeriod
+	if got := f.nextRolling(now); !got.IsZero() {
+		t.Errorf("no period: got %v", got)
+	}
+}
+
+func TestPeriodRolling(t *testing.T) {
+	defer os.Remove(testFiles[0])
+	defer os.Remove(testFiles[1])
+
+	if err := ioutil.WriteFile(testFiles[0], []byte(testString), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	os.Chtimes(testFiles[0], yesterday, yesterday)
+
+	f, _ := OpenFile(testFiles[0], 0, 1)
+	f.Period = Daily
+	f.Write([]byte(testLongString))
+	f.Write([]byte(testLongString))
+	f.Close()
+
+	if contents, err := ioutil.ReadFile(testFiles[1]); err != nil || string(contents) != testString {
+		t.Errorf("backup: %q, %v", contents, err)
+	}
+	if contents, err := ioutil.ReadFile(testFiles[0]); err != nil || string(contents) != testLongString+testLongString {
+		t.Errorf("file: %q, %v", contents, err)
+	}
+}
+
+func readGzip(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadAll(zr)
+	return string(b), err
+}
+
+func TestCompress(t *testing.T) {
+	backups := []string{"_test.1.log", "_test.2.log"}
+	defer func() {
+		os.Remove(testFiles[0])
+		for _, backup := range backups {
+			os.Remove(backup)
+			os.Remove(backup + ".gz")
+			os.Remove(backup + ".gz.tmp")
+		}
+	}()
+
+	// left by a process exiting during the compression
+	ioutil.WriteFile(backups[0], []byte("0"), 0644)
+	ioutil.WriteFile(backups[0]+".gz.tmp", []byte("broken"), 0644)
+
+	f, _ := OpenFile(testFiles[0], 0, 2)
+	f.Compress = true
+	for _, s := range []string{"1", "2", "3"} {
+		f.Write([]byte(s))
+		f.rolling(f.BackupFiles, time.Now())
+	}
+	f.Close()
+
+	for i, want := range []string{"3", "2"} {
+		if _, err := os.Stat(backups[i]); err == nil {
+			t.Errorf("%s is not compressed", backups[i])
+		}
+		if _, err := os.Stat(backups[i] + ".gz.tmp"); err == nil {
+			t.Errorf("%s.gz.tmp is not removed", backups[i])
+		}
+		if got, err := readGzip(backups[i] + ".gz"); err != nil || got != want {
+			t.Errorf("%s.gz: %q, %v, want %q", backups[i], got, err, want)
+		}
+	}
+	if _, err := os.Stat("_test.3.log.gz"); err == nil {
+		os.Remove("_test.3.log.gz")
+		t.Errorf("too much backup files")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	backups := []string{"_test.1.log", "_test.2.log", "_test.3.log"}
+	defer func() {
+		os.Remove(testFiles[0])
+		for _, backup := range backups {
+			os.Remove(backup)
+		}
+	}()
+
+	old := time.Now().AddDate(0, 0, -3)
+	for _, backup := range backups {
+		ioutil.WriteFile(backup, []byte(testString), 0644)
+	}
+	os.Chtimes(backups[2], old, old)
+
+	// at open
+	f, _ := OpenFile(testFiles[0], 0, 3)
+	f.MaxAge = 2
+	f.Write([]byte(testString))
+	if _, err := os.Stat(backups[2]); err == nil {
+		t.Errorf("%s is not expired", backups[2])
+	}
+
+	// after rolling, the two newest backups exceed the total size
+	f.MaxTotalSize = int64(len(testString)) + 1
+	f.rolling(f.BackupFiles, time.Now())
+	f.Close()
+	if _, err := os.Stat(backups[0]); err != nil {
+		t.Errorf("%s: %v", backups[0], err)
+	}
+	for _, backup := range backups[1:] {
+		if _, err := os.Stat(backup); err == nil {
+			t.Errorf("%s exceeds the total size", backup)
+		}
+	}
+}
+
+func TestStamped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	other := filepath.Join(dir, "app-other.log")
+	ioutil.WriteFile(other, []byte(testString), 0644)
+
+	f, _ := OpenFile(path, 0, 2)
+	f.TimeFormat = DefaultTimeFormat
+	f.Symlink = filepath.Join(dir, "current")
+	for _, s := range []string{"1", "2", "3"} {
+		f.Write([]byte(s))
+		f.rolling(f.BackupFiles, time.Now())
+	}
+	f.Write([]byte("4"))
+	f.Close()
+
+	backups := f.stamped()
+	if len(backups) != 2 {
+		t.Fatalf("backups: %q", backups)
+	}
+	for i, want := range []string{"3", "2"} {
+		if contents, err := ioutil.ReadFile(backups[i]); err != nil || string(contents) != want {
+			t.Errorf("%s: %q, %v, want %q", backups[i], contents, err, want)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("%s: %v", other, err)
+	}
+	if contents, err := ioutil.ReadFile(f.Symlink); err != nil || string(contents) != "4" {
+		t.Errorf("symlink: %q, %v", contents, err)
+	}
+}
+
+func TestConcurrentWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, _ := OpenFile(path, 1024, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				f.Write([]byte(testLongString + "\n"))
+			}
+		}()
+	}
+	wg.Wait()
+	f.Close()
+
+	var lines int
+	paths, _ := filepath.Glob(path[:len(path)-len(".log")] + "*")
+	for _, path := range paths {
+		contents, _ := ioutil.ReadFile(path)
+		for _, line := range strings.SplitAfter(string(contents), "\n") {
+			if line == "" {
+				continue
+			}
+			if line != testLongString+"\n" {
+				t.Fatalf("%s: malformed line %q", path, line)
+			}
+			lines++
+		}
+	}
+	if lines != 800 {
+		t.Errorf("got %d lines, want 800", lines)
+	}
+}
+
+func TestFlushPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, _ := OpenFile(path, 0, 1)
+	defer f.Close()
+	f.FlushInterval = 50 * time.Millisecond
+	f.FlushLevel = "error"
+
+	size := func() int64 {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return 0
+		}
+		return fi.Size()
+	}
+
+	out := multi.NewWriter(f, &multi.TextFormatter{})
+	out.OutputRecord(1, &multi.Record{Level: multi.Linfo, Message: testString})
+	if n := size(); n != 0 {
+		t.Fatalf("info is flushed at once: %d bytes", n)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := size(); n == 0 {
+		t.Fatalf("info is not flushed after the interval")
+	}
+
+	n := size()
+	out.OutputRecord(1, &multi.Record{Level: multi.Lerror, Message: testString})
+	if size() == n {
+		t.Errorf("error is not flushed at once")
+	}
+}
+
+func TestIsSevere(t *testing.T) {
+	f := &File{FlushLevel: "error"}
+	if f.isSevere(multi.Lwarn) || !f.isSevere(multi.Lerror) || !f.isSevere(multi.Lfatal) {
+		t.Errorf("error: %q", f.flushLevel)
+	}
+	f.FlushLevel = "warn"
+	if !f.isSevere(multi.Lwarn) || f.isSevere(multi.Linfo) {
+		t.Errorf("changed to warn: %q", f.flushLevel)
+	}
+	f.FlushLevel = "loud"
+	if f.isSevere(multi.Lfatal) {
+		t.Errorf("unknown level: %q", f.flushLevel)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	moved := path + ".moved"
+	f, _ := OpenFile(path, 0, 1)
+	f.FlushInterval = 0
+	f.CheckInterval = time.Nanosecond
+	defer f.Close()
+
+	f.Write([]byte("1"))
+	os.Rename(path, moved)
+	f.Write([]byte("2"))
+
+	if contents, _ := ioutil.ReadFile(moved); string(contents) != "1" {
+		t.Errorf("moved: %q", contents)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "2" {
+		t.Errorf("reopened: %q", contents)
+	}
+
+	// truncated
+	os.Truncate(path, 0)
+	f.Write([]byte("3"))
+	if f.size != 1 {
+		t.Errorf("size: got %d, want 1", f.size)
+	}
+
+	// explicit
+	f.CheckInterval = 0
+	Register(f)
+	os.Rename(path, moved)
+	if err := ReopenAll(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("4"))
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "4" {
+		t.Errorf("reopened: %q", contents)
+	}
+}
+
+func TestProcessLock(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("flock is not supported")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// the files are opened twice, like by two processes
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		f, _ := OpenFile(path, 2048, 1000)
+		f.Buffersize = 256
+		f.ProcessLock = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				f.Write([]byte(testLongString + "\n"))
+			}
+			f.Close()
+		}()
+	}
+	wg.Wait()
+	os.Remove(path + ".lock")
+
+	var lines int
+	paths, _ := filepath.Glob(path[:len(path)-len(".log")] + "*")
+	for _, path := range paths {
+		contents, _ := ioutil.ReadFile(path)
+		for _, line := range strings.SplitAfter(string(contents), "\n") {
+			if line == "" {
+				continue
+			}
+			if line != testLongString+"\n" {
+				t.Fatalf("%s: malformed line %q", path, line)
+			}
+			lines++
+		}
+	}
+	if lines != 400 {
+		t.Errorf("got %d lines, want 400", lines)
+	}
+}
+
+func TestProcessLockFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// the lock file cannot be opened
+	os.Mkdir(path+".lock", 0750)
+
+	var errs []error
+	f, _ := OpenFile(path, 0, 1)
+	f.Buffersize = 0
+	f.ProcessLock = true
+	f.OnError = func(err error) { errs = append(errs, err) }
+	if _, err := f.Write([]byte(testString)); err == nil || len(errs) != 1 {
+		t.Errorf("write without the lock: %v, %v", err, errs)
+	}
+	f.Close()
+	if contents, _ := ioutil.ReadFile(path); len(contents) != 0 {
+		t.Errorf("written without the lock: %q", contents)
+	}
+}
+
+func TestRotateHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, _ := OpenFile(path, 10, 1)
+	f.Compress = true
+
+	var pre []RotateEvent
+	f.OnPreRotate = func(e RotateEvent) {
+		pre = append(pre, e)
+		// the writers are not blocked
+		done := make(chan struct{})
+		go func() {
+			f.Write([]byte("during\n"))
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("write is blocked by OnPreRotate")
+		}
+	}
+	post := make(chan RotateEvent, 1)
+	f.OnPostRotate = func(e RotateEvent) { post <- e }
+	events := f.Events()
+
+	f.Write([]byte(testLongString))
+	f.Write([]byte(testLongString))
+	f.Close()
+
+	backup := path[:len(path)-len(".log")] + ".1.log"
+	if len(pre) != 1 || pre[0].Path != path || pre[0].Backup != backup {
+		t.Errorf("pre: %+v", pre)
+	}
+	want := RotateEvent{Path: path, Backup: backup + ".gz"}
+	for _, e := range []RotateEvent{<-post, <-events} {
+		if e.Path != want.Path || e.Backup != want.Backup || e.Time.IsZero() {
+			t.Errorf("post: %+v, want %+v", e, want)
+		}
+	}
+	if got, _ := readGzip(backup + ".gz"); got != testLongString+"during\n" {
+		t.Errorf("backup: %q", got)
+	}
+}
+
+func TestSlowPostRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, _ := OpenFile(path, 10, 3)
+
+	var (
+		mu       sync.Mutex
+		contents []string
+	)
+	delivered := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(contents)
+	}
+	release := make(chan struct{})
+	f.OnPostRotate = func(e RotateEvent) {
+		<-release
+		b, _ := ioutil.ReadFile(e.Backup)
+		mu.Lock()
+		contents = append(contents, string(b))
+		mu.Unlock()
+	}
+	events := f.Events()
+	closed := make(chan struct{})
+	go func() {
+		for range events {
+		}
+		close(closed)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		// rolled after A, then not rolled until the event is delivered
+		for _, s := range []string{"A", "B", "C"} {
+			f.Write([]byte(strings.Repeat(s, 11)))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("write is blocked by OnPostRotate")
+	}
+	close(release)
+	for deadline := time.Now().Add(time.Second); delivered() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	f.Write([]byte("D"))
+	f.Close()
+
+	want := []string{strings.Repeat("A", 11), strings.Repeat("B", 11) + strings.Repeat("C", 11)}
+	if !slices.Equal(contents, want) {
+		t.Errorf("post: %q, want %q", contents, want)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("events are not closed")
+	}
+}
+
+func TestPreRotateTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, _ := OpenFile(path, 10, 3)
+	f.TimeFormat = "20060102T150405,000000000"
+
+	var pre RotateEvent
+	f.OnPreRotate = func(e RotateEvent) {
+		pre = e
+		time.Sleep(10 * time.Millisecond)
+	}
+	post := f.Events()
+
+	f.Write([]byte(testLongString))
+	f.Write([]byte(testLongString))
+	f.Close()
+
+	e := <-post
+	if pre.Backup == "" || e.Backup != pre.Backup || !e.Time.Equal(pre.Time) {
+		t.Errorf("pre: %+v, post: %+v", pre, e)
+	}
+	if _, err := os.Stat(pre.Backup); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenFileOptions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	path := filepath.Join(dir, "app.log")
+
+	if _, err := OpenFile(path, 0, 1); err == nil {
+		t.Errorf("missing directory is not reported")
+	}
+	f, err := OpenFile(path, 0, 1, WithMkdirAll(0750), WithFileMode(0640))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Uid, f.Gid = os.Getuid(), os.Getgid()
+	if _, err := f.Write([]byte(testString)); err != nil {
+		t.Error(err)
+	}
+	f.Close()
+
+	if runtime.GOOS != "windows" {
+		if fi, err := os.Stat(dir); err != nil || fi.Mode().Perm()&^0750 != 0 {
+			t.Errorf("directory: %v, %v", fi.Mode(), err)
+		}
+		if fi, err := os.Stat(path); err != nil || fi.Mode().Perm()&^0640 != 0 {
+			t.Errorf("file: %v, %v", fi.Mode(), err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file is left: %v", entries)
+	}
+
+	if _, err := OpenFile(filepath.Join(path, "app.log"), 0, 1); err == nil {
+		t.Errorf("file as directory is not reported")
+	}
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		os.Chmod(dir, 0500)
+		defer os.Chmod(dir, 0750)
+		if _, err := OpenFile(filepath.Join(dir, "other.log"), 0, 1); err == nil {
+			t.Errorf("read only directory is not reported")
+		}
+	}
+}
+
+func TestFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sub")
+	os.Mkdir(dir, 0750)
+	path := filepath.Join(dir, "app.log")
+
+	var (
+		mu   sync.Mutex
+		errs int
+	)
+	errors := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return errs
+	}
+	f, _ := OpenFile(path, 0, 1)
+	defer f.Close()
+	f.Buffersize = 0
+	f.RetryInterval = 10 * time.Millisecond
+	f.OnError = func(err error) {
+		mu.Lock()
+		errs++
+		mu.Unlock()
+	}
+
+	// the writes fail until the directory is created again
+	os.Remove(dir)
+
+	if _, err := f.Write([]byte("1")); err == nil || errors() != 1 {
+		t.Errorf("ReturnError: %v, %d errors", err, errors())
+	}
+
+	var fallback bytes.Buffer
+	f.Failure = FallbackStderr
+	f.Fallback = &fallback
+	if _, err := f.Write([]byte("2")); err != nil || fallback.String() != "2" {
+		t.Errorf("FallbackStderr: %v, %q", err, fallback.String())
+	}
+
+	f.Failure = Drop
+	if _, err := f.Write([]byte("3")); err != nil || f.Dropped() != 1 {
+		t.Errorf("Drop: %v, %d dropped", err, f.Dropped())
+	}
+
+	// retried in the background, the writer does not wait
+	f.Failure = Retry
+	f.Retries = 2
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	if _, err := f.Write([]byte("4")); err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("Retry: %v, blocked %v", err, time.Since(start))
+	}
+	for deadline := time.Now().Add(time.Second); f.Dropped() != 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if f.Dropped() != 2 || errors() != 4 {
+		t.Errorf("Retry: %d dropped, %d errors", f.Dropped(), errors())
+	}
+
+	// recovered, the data kept meanwhile is written by the retry
+	os.Mkdir(dir, 0750)
+	if _, err := f.Write([]byte("5")); err != nil {
+		t.Errorf("recovery: %v", err)
+	}
+	var contents []byte
+	for deadline := time.Now().Add(time.Second); string(contents) != "5" && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		contents, _ = ioutil.ReadFile(path)
+	}
+	if string(contents) != "5" {
+		t.Errorf("recovery: %q", contents)
+	}
+	if _, err := f.Write([]byte("6")); err != nil {
+		t.Errorf("recovered: %v", err)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "56" {
+		t.Errorf("recovered: %q", contents)
+	}
+}
+
+// syncBuffer is a bytes.Buffer written by the background flushing.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFlushFailure(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("no /dev/full")
+	}
+
+	var fallback syncBuffer
+	f, err := OpenFile("/dev/full", 0, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	f.Failure = FallbackStderr
+	f.Fallback = &fallback
+	f.FlushInterval = 10 * time.Millisecond
+	if n, err := f.Write([]byte("buffered\n")); n != 9 || err != nil {
+		t.Errorf("write: %d, %v", n, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	f.Close()
+	if fallback.String() != "buffered\n" || f.Dropped() != 0 {
+		t.Errorf("fallback: %q, %d dropped", fallback.String(), f.Dropped())
+	}
+
+	f, _ = OpenFile("/dev/full", 0, 0)
+	f.Failure = Drop
+	f.Write([]byte("1\n"))
+	f.Write([]byte("2\n"))
+	if err := f.Flush(); err == nil || f.Dropped() != 2 {
+		t.Errorf("drop: %v, %d dropped", err, f.Dropped())
+	}
+	f.Close()
+}
+
+// shortWriter writes n bytes, then fails.
+type shortWriter struct {
+	n int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	n := min(w.n, len(p))
+	w.n -= n
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+func TestPartialFlush(t *testing.T) {
+	f, _ := OpenFile(filepath.Join(t.TempDir(), "app.log"), 0, 0)
+	defer f.Close()
+	f.Failure = Drop
+	f.FlushInterval = time.Hour
+	f.Write([]byte("0\n"))
+	f.Flush()
+
+	// the first write is flushed, the others are lost
+	f.mu.Lock()
+	f.bufWriter.Reset(unflushed{f, &shortWriter{3}})
+	f.mu.Unlock()
+	for _, s := range []string{"1\n", "2\n", "3\n"} {
+		f.Write([]byte(s))
+	}
+	if err := f.Flush(); err == nil || f.Dropped() != 2 {
+		t.Errorf("partial flush: %v, %d dropped", err, f.Dropped())
+	}
+}
+
+func TestMaxRetrySize(t *testing.T) {
+	defer func(size int) { MaxRetrySize = size }(MaxRetrySize)
+	MaxRetrySize = 4
+
+	dir := filepath.Join(t.TempDir(), "sub")
+	os.Mkdir(dir, 0750)
+	f, _ := OpenFile(filepath.Join(dir, "app.log"), 0, 0)
+	defer f.Close()
+	f.Failure = Retry
+	f.RetryInterval = time.Hour
+	os.Remove(dir)
+
+	if _, err := f.Write([]byte("123")); err != nil || f.Dropped() != 0 {
+		t.Errorf("kept: %v, %d dropped", err, f.Dropped())
+	}
+	if n, err := f.Write([]byte("45")); err != ErrSuspended || f.Dropped() != 1 {
+		t.Errorf("overflow: %d, %v, %d dropped", n, err, f.Dropped())
+	}
+	f.mu.Lock()
+	backlog := string(f.backlog)
+	f.mu.Unlock()
+	if backlog != "123" {
+		t.Errorf("backlog: %q", backlog)
+	}
+}
+
 func BenchmarkNoBuffer(b *testing.B) {
 	f, _ := Open(benchLogFiles[0])
 	f.Buffersize = 0
@@ -0,0 +1,95 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// RotateEvent describes a rolling of the file.
+type RotateEvent struct {
+	Path   string    // the path of the file
+	Backup string    // the path of the backup file, empty if none
+	Time   time.Time // the time of rolling
+}
+
+// EventsSize is the capacity of the channel returned by File.Events.
+var EventsSize = 16
+
+// Events returns the channel receiving the events after rolling, like
+// OnPostRotate. The events are dropped if the channel is full. The
+// channel is closed by Close.
+func (f *File) Events() <-chan RotateEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.events == nil {
+		f.events = make(chan RotateEvent, EventsSize)
+	}
+	return f.events
+}
+
+// backupPath returns the path which the file is renamed to by rolling
+// at the time, or the empty string if the file is removed.
+func (f *File) backupPath(now time.Time) string {
+	if f.BackupFiles < 1 {
+		return ""
+	}
+	ext := filepath.Ext(f.FilePath)
+	name := f.FilePath[0 : len(f.FilePath)-len(ext)]
+	if f.TimeFormat != "" {
+		return f.stampedPath(name, ext, now)
+	}
+	return name + ".1" + ext
+}
+
+// preRotate calls OnPreRotate with f.mu released. The other writers
+// keep writing to the file meanwhile, but do not roll it.
+func (f *File) preRotate(now time.Time) {
+	e := RotateEvent{Path: f.FilePath, Backup: f.backupPath(now), Time: now}
+
+	f.rotating = true
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.rotating = false
+	}()
+
+	f.OnPreRotate(e)
+}
+
+// notifying reports whether an event is not delivered yet. The file is
+// not rolled meanwhile, so the backup file of the event is not renamed
+// before the hooks see it. It must be called with f.mu held.
+func (f *File) notifying() bool {
+	if f.notified == nil {
+		return false
+	}
+	select {
+	case <-f.notified:
+		return false
+	default:
+		return true
+	}
+}
+
+// notifier returns the function delivering the event after rolling, or
+// nil if nobody is listening. It must be called with f.mu held.
+func (f *File) notifier() func(e RotateEvent) {
+	post, events := f.OnPostRotate, f.events
+	if post == nil && events == nil {
+		return nil
+	}
+	return func(e RotateEvent) {
+		if post != nil {
+			post(e)
+		}
+		if events != nil {
+			select {
+			case events <- e:
+			default:
+			}
+		}
+	}
+}
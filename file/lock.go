@@ -0,0 +1,115 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const lockExt = ".lock"
+
+// lockProcess locks the lock file "path.lock" shared by the processes
+// writing to the path, and returns the function unlocking it. The lock
+// is a no-op on the platforms without flock.
+func (f *File) lockProcess() (unlock func(), err error) {
+	if f.lock == nil {
+		file, err := os.OpenFile(f.FilePath+lockExt, os.O_RDWR|os.O_CREATE, f.FileMode)
+		if err != nil {
+			return nil, err
+		}
+		f.chown(file)
+		f.lock = file
+	}
+	if err := flock(f.lock); errors.Is(err, errors.ErrUnsupported) {
+		return func() {}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return func() { funlock(f.lock) }, nil
+}
+
+func (f *File) closeLock() {
+	if f.lock != nil {
+		f.lock.Close()
+		f.lock = nil
+	}
+}
+
+// lockedWriter writes to the file holding the process lock.
+type lockedWriter struct {
+	f *File
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	return w.f.writeLocked(p)
+}
+
+// writeLocked writes p with a single write holding the process lock. If
+// another process rolled the file, it is reopened before.
+func (f *File) writeLocked(p []byte) (n int, err error) {
+	unlock, err := f.lockProcess()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if f.changed() {
+		f.file.Close()
+		file, err := os.OpenFile(f.FilePath, DefaultFileFlag, f.FileMode)
+		if err != nil {
+			return 0, err
+		}
+		f.file = file
+		f.size = 0
+		f.next = time.Time{}
+	}
+
+	n, err = f.file.Write(p)
+
+	// count the writes of the other processes
+	if fi, err := f.file.Stat(); err == nil && fi.Size() > f.size {
+		f.size = fi.Size()
+	}
+	return
+}
+
+// rollingLocked rolls the file holding the process lock, unless
+// another process rolled it already.
+func (f *File) rollingLocked(now time.Time) {
+	// the buffer is written to the current file at first
+	f.flush()
+
+	unlock, err := f.lockProcess()
+	if err != nil {
+		// rolled by the next write
+		f.errs = append(f.errs, err)
+		return
+	}
+	defer unlock()
+
+	fi, err := os.Stat(f.FilePath)
+	if err != nil {
+		// removed, or rolled without backup
+		f.close()
+		return
+	}
+	if f.file != nil {
+		if ofi, err := f.file.Stat(); err != nil || !os.SameFile(fi, ofi) {
+			// rolled by another process
+			f.close()
+			f.next = time.Time{}
+			return
+		}
+	}
+
+	f.size = fi.Size()
+	f.next = time.Time{}
+	if f.Period != NoPeriod {
+		f.next = f.nextRolling(fi.ModTime())
+	}
+	if f.LimitSize > 0 && f.size > f.LimitSize || f.isTimeout(now) {
+		f.rolling(f.BackupFiles, now)
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package file
+
+import (
+	"errors"
+	"os"
+)
+
+// The processes write without locking on this platform.
+
+func flock(file *os.File) error {
+	return errors.ErrUnsupported
+}
+
+func funlock(file *os.File) error {
+	return errors.ErrUnsupported
+}
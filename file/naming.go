@@ -0,0 +1,142 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeFormat is a sortable time format which is valid in file
+// names, see File.TimeFormat.
+const DefaultTimeFormat = "2006-01-02T15-04-05"
+
+type stampedFile struct {
+	path string
+	t    time.Time
+	n    int // counter of the files rolled in the same second
+}
+
+// parseStamp returns the time and the counter of a backup file name
+// like "name-2006-01-02T15-04-05.ext" or "name-2006-01-02T15-04-05.1.ext".
+func (f *File) parseStamp(base, prefix, ext string) (t time.Time, n int, ok bool) {
+	base = strings.TrimSuffix(base, gzipExt)
+	if !strings.HasPrefix(base, prefix) || !strings.HasSuffix(base, ext) ||
+		len(base) < len(prefix)+len(ext) {
+		return
+	}
+	s := base[len(prefix) : len(base)-len(ext)]
+	if t, err := time.ParseInLocation(f.TimeFormat, s, f.location()); err == nil {
+		return t, 0, true
+	}
+	i := strings.LastIndexByte(s, '.')
+	if i < 0 {
+		return
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return
+	}
+	t, err = time.ParseInLocation(f.TimeFormat, s[:i], f.location())
+	return t, n, err == nil
+}
+
+// stamped returns the existing timestamped backup files, the newest
+// first. Other files in the directory are ignored.
+func (f *File) stamped() []string {
+	dir, base := filepath.Split(f.FilePath)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "-"
+
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+	var files []stampedFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if t, n, ok := f.parseStamp(entry.Name(), prefix, ext); ok {
+			files = append(files, stampedFile{dir + entry.Name(), t, n})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].t.Equal(files[j].t) {
+			return files[i].t.After(files[j].t)
+		}
+		return files[i].n > files[j].n
+	})
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+	return paths
+}
+
+// rollingStamped renames the file with a single rename, and removes the
+// backup files exceeding n.
+func (f *File) rollingStamped(name, ext string, n int, e *RotateEvent) {
+	// the backup files are listed below, so wait for the last housekeeping
+	f.pending.Wait()
+
+	for _, path := range f.stamped() {
+		if !strings.HasSuffix(path, gzipExt) {
+			cleanup(path)
+		}
+	}
+
+	path := f.stampedPath(name, ext, e.Time)
+	if err := os.Rename(f.FilePath, path); err != nil && !os.IsNotExist(err) {
+		f.errs = append(f.errs, err)
+	}
+	e.Backup = path
+
+	var slots []string
+	for i, path := range f.stamped() {
+		if i >= n {
+			// Too much backup files
+			os.Remove(path)
+		} else if f.Compress && !strings.HasSuffix(path, gzipExt) {
+			slots = append(slots, path)
+		}
+	}
+	f.housekeeping(slots, e)
+}
+
+// stampedPath returns the unused backup file path of the time.
+func (f *File) stampedPath(name, ext string, t time.Time) string {
+	stamp := name + "-" + t.In(f.location()).Format(f.TimeFormat)
+	path := stamp + ext
+	for i := 1; backup(path) != ""; i++ {
+		path = stamp + "." + strconv.Itoa(i) + ext
+	}
+	return path
+}
+
+// link points the symbolic link Symlink to the file. The link is
+// replaced by a rename, so it always exists.
+func (f *File) link() error {
+	target, err := filepath.Abs(f.FilePath)
+	if err != nil {
+		return err
+	}
+	if dir, err := filepath.Abs(filepath.Dir(f.Symlink)); err == nil && dir == filepath.Dir(target) {
+		target = filepath.Base(target)
+	}
+	if old, err := os.Readlink(f.Symlink); err == nil && old == target {
+		return nil
+	}
+
+	tmp := f.Symlink + tmpExt
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Symlink)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var DefaultDirMode = os.FileMode(0770)
+
+// Option configures the file opened by OpenFile.
+type Option func(f *File)
+
+// WithMkdirAll creates the directory of the file and its parents with
+// the mode (before umask) if necessary.
+func WithMkdirAll(mode os.FileMode) Option {
+	return func(f *File) {
+		f.mkdirAll = true
+		f.dirMode = mode
+	}
+}
+
+// WithFileMode creates the file with the mode (before umask).
+func WithFileMode(mode os.FileMode) Option {
+	return func(f *File) {
+		f.FileMode = mode
+	}
+}
+
+// prepare creates the directory if required, and checks that the file
+// can be written, so a bad location is reported before the first write.
+func (f *File) prepare() error {
+	dir := filepath.Dir(f.FilePath)
+	if f.mkdirAll {
+		if err := os.MkdirAll(dir, f.dirMode); err != nil {
+			return err
+		}
+	}
+	if stat, err := os.Stat(dir); err != nil {
+		return err
+	} else if !stat.IsDir() {
+		return fmt.Errorf("Error: The file path %s: is not a directory.", f.FilePath)
+	}
+
+	if _, err := os.Stat(f.FilePath); err == nil {
+		file, err := os.OpenFile(f.FilePath, DefaultFileFlag, f.FileMode)
+		if err != nil {
+			return fmt.Errorf("Error: The file path %s: is not writable: %w", f.FilePath, err)
+		}
+		return file.Close()
+	}
+
+	// not creating the file before the first write
+	file, err := os.CreateTemp(dir, "."+filepath.Base(f.FilePath)+".*"+tmpExt)
+	if err != nil {
+		return fmt.Errorf("Error: The file path %s: is not writable: %w", f.FilePath, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// chown changes the owner and the group of the file if configured.
+func (f *File) chown(file *os.File) error {
+	if f.Uid == -1 && f.Gid == -1 {
+		return nil
+	}
+	if err := chown(file, f.Uid, f.Gid); err != nil {
+		return fmt.Errorf("Error: The file path %s: %w", file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"os"
+	"time"
+)
+
+// Period is the interval of the time-based rolling.
+type Period int
+
+const (
+	NoPeriod Period = iota // rolling by size only
+	Hourly                 // rolling at the beginning of every hour
+	Daily                  // rolling at midnight
+)
+
+func (f *File) location() *time.Location {
+	if f.Location == nil {
+		return time.Local
+	}
+	return f.Location
+}
+
+// nextRolling returns the beginning of the period following the one
+// containing t, in the time zone f.Location.
+func (f *File) nextRolling(t time.Time) time.Time {
+	loc := f.location()
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch f.Period {
+	case Hourly:
+		return time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+	case Daily:
+		return time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
+
+// modTime returns the time of the last write to the file, or now if
+// the file does not exist yet.
+func (f *File) modTime(now time.Time) time.Time {
+	if f.file != nil {
+		return now
+	}
+	fi, err := os.Stat(f.FilePath)
+	if err != nil {
+		return now
+	}
+	return fi.ModTime()
+}
+
+// isTimeout reports whether the period of the file is over. It is
+// cheap enough to be checked on every write.
+func (f *File) isTimeout(now time.Time) bool {
+	if f.Period == NoPeriod {
+		return false
+	}
+	if f.next.IsZero() {
+		f.next = f.nextRolling(f.modTime(now))
+	}
+	return !now.Before(f.next)
+}
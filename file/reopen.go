@@ -0,0 +1,122 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+var DefaultCheckInterval = time.Second
+
+// changed reports whether the path is not the open file any more, e.g.
+// moved or removed by logrotate. The size is corrected if the file is
+// truncated, e.g. by logrotate with copytruncate.
+func (f *File) changed() bool {
+	fi, err := os.Stat(f.FilePath)
+	if err != nil {
+		return true
+	}
+	ofi, err := f.file.Stat()
+	if err != nil || !os.SameFile(fi, ofi) {
+		return true
+	}
+
+	var buffered int64
+	if f.bufWriter != nil {
+		buffered = int64(f.bufWriter.Buffered())
+	}
+	if fi.Size() < f.size-buffered {
+		f.size = fi.Size() + buffered
+	}
+	return false
+}
+
+// check closes the file if it is changed, so it is reopened at the next
+// write. The path is checked every CheckInterval.
+func (f *File) check(now time.Time) {
+	if f.file == nil || f.CheckInterval <= 0 || now.Sub(f.checked) < f.CheckInterval {
+		return
+	}
+	f.checked = now
+	if f.changed() {
+		f.close()
+	}
+}
+
+// Reopen closes the file, and opens the path again. It is used after
+// the file is moved by another process.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.close()
+	return f.open()
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[*File]struct{})
+	hupOnce    sync.Once
+)
+
+// Register adds the file to the files reopened by ReopenAll. Close
+// removes it.
+func Register(f *File) {
+	registryMu.Lock()
+	registry[f] = struct{}{}
+	registryMu.Unlock()
+}
+
+func unregister(f *File) {
+	registryMu.Lock()
+	delete(registry, f)
+	registryMu.Unlock()
+}
+
+// ReopenAll reopens the registered files. The error is the first one
+// returned by Reopen.
+func ReopenAll() (err error) {
+	registryMu.Lock()
+	files := make([]*File, 0, len(registry))
+	for f := range registry {
+		files = append(files, f)
+	}
+	registryMu.Unlock()
+
+	for _, f := range files {
+		if e := f.Reopen(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// NotifySIGHUP relays SIGHUP to c like signal.Notify, and reports
+// whether the system has SIGHUP.
+func NotifySIGHUP(c chan<- os.Signal) bool {
+	if sighup == nil {
+		return false
+	}
+	signal.Notify(c, sighup)
+	return true
+}
+
+// HandleSIGHUP reopens the registered files whenever the process
+// receives SIGHUP, e.g. from the postrotate script of logrotate. It
+// does nothing on systems without SIGHUP.
+func HandleSIGHUP() {
+	hupOnce.Do(func() {
+		c := make(chan os.Signal, 1)
+		if !NotifySIGHUP(c) {
+			return
+		}
+		go func() {
+			for range c {
+				ReopenAll()
+			}
+		}()
+	})
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// backups returns the existing backup files, the newest first.
+func (f *File) backups() (paths []string) {
+	if f.TimeFormat != "" {
+		return f.stamped()
+	}
+	ext := filepath.Ext(f.FilePath)
+	name := f.FilePath[0 : len(f.FilePath)-len(ext)]
+	for i := 1; i <= f.BackupFiles; i++ {
+		path := backup(name + "." + strconv.Itoa(i) + ext)
+		if path == "" {
+			break
+		}
+		paths = append(paths, path)
+	}
+	return
+}
+
+// retain removes the backup files older than MaxAge days, and the
+// oldest backup files exceeding MaxTotalSize in total.
+func (f *File) retain() {
+	if f.MaxAge <= 0 && f.MaxTotalSize <= 0 {
+		return
+	}
+	expired := time.Now().AddDate(0, 0, -f.MaxAge)
+
+	var total int64
+	remove := false
+	for _, path := range f.backups() {
+		if !remove {
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			total += fi.Size()
+			remove = f.MaxAge > 0 && fi.ModTime().Before(expired) ||
+				f.MaxTotalSize > 0 && total > f.MaxTotalSize
+		}
+		if remove {
+			// the older ones are removed too, leaving no gap in the slots
+			os.Remove(path)
+		}
+	}
+}
+
+// housekeeping compresses the backup files, enforces the retention,
+// then delivers the event of rolling in the background. Rolling and
+// Close wait for the housekeeping, but only Close waits for the event,
+// so slow hooks do not block the writers. It must be called with f.mu
+// held.
+func (f *File) housekeeping(slots []string, e *RotateEvent) {
+	notify := f.notifier()
+	if len(slots) == 0 && f.MaxAge <= 0 && f.MaxTotalSize <= 0 && notify == nil {
+		return
+	}
+	mode := f.FileMode
+	var prev, done chan struct{}
+	if notify != nil {
+		// the events are delivered in order
+		prev, done = f.notified, make(chan struct{})
+		f.notified = done
+	}
+	f.pending.Add(1)
+	go func() {
+		for _, slot := range slots {
+			compress(slot, mode)
+		}
+		f.retain()
+		if e.Backup != "" {
+			// compressed, or removed by the retention
+			e.Backup = backup(e.Backup)
+		}
+		f.pending.Done()
+
+		if notify != nil {
+			defer close(done)
+			if prev != nil {
+				<-prev
+			}
+			notify(*e)
+		}
+	}()
+}
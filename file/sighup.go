@@ -0,0 +1,12 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+//go:build !js
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+var sighup os.Signal = syscall.SIGHUP
@@ -0,0 +1,10 @@
+// Copyright (C) 2021, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+//go:build js
+
+package file
+
+import "os"
+
+// sighup is nil, js has no SIGHUP.
+var sighup os.Signal
@@ -1,3 +1,3 @@
 module github.com/ccpaging/log
 
-go 1.18
+go 1.21
@@ -0,0 +1,225 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Overflow is the policy of an asynchronous logger when its queue is full.
+type Overflow int
+
+const (
+	// Block waits until there is room in the queue.
+	Block Overflow = iota
+	// DropNewest discards the record being logged.
+	DropNewest
+	// DropOldest discards the oldest record in the queue.
+	DropOldest
+	// DropBelow discards the record being logged if its level is
+	// below AsyncOptions.Level, and waits otherwise.
+	DropBelow
+)
+
+// AsyncOptions configures the asynchronous mode, see Multi.SetAsync.
+type AsyncOptions struct {
+	Size     int      // capacity of the queue, DefaultAsyncSize if <= 0
+	Overflow Overflow // policy when the queue is full
+	Level    string   // the minimum level not dropped by DropBelow
+}
+
+var DefaultAsyncSize = 1024
+
+type asyncEntry struct {
+	l *Multi
+	r *Record
+}
+
+type asyncQueue struct {
+	mu      sync.Mutex
+	full    *sync.Cond   // signaled when the worker takes the queue
+	ready   *sync.Cond   // signaled when an entry is queued or the queue is closed
+	ring    []asyncEntry // the queued entries, n from head
+	head    int
+	n       int
+	opts    AsyncOptions
+	level   int32
+	idle    chan struct{} // closed when the queue is drained, nil if idle
+	closed  bool
+	done    chan struct{}
+	dropped *atomic.Uint64 // shared with the loggers, see Multi.Dropped
+}
+
+// levelSeverity returns the severity of the level, the lowest one if
+// the level is unknown.
+func levelSeverity(level string) int32 {
+	if n, ok := severity(level); ok {
+		return n
+	}
+	return noLevel
+}
+
+func newAsyncQueue(opts AsyncOptions, dropped *atomic.Uint64) *asyncQueue {
+	if opts.Size <= 0 {
+		opts.Size = DefaultAsyncSize
+	}
+	q := &asyncQueue{
+		ring:  make([]asyncEntry, opts.Size),
+		opts:  opts,
+		level: levelSeverity(opts.Level),
+		done:  make(chan struct{}),
+
+		dropped: dropped,
+	}
+	q.full = sync.NewCond(&q.mu)
+	q.ready = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *asyncQueue) push(l *Multi, r *Record) error {
+	q.mu.Lock()
+	for !q.closed && q.n >= len(q.ring) {
+		switch q.opts.Overflow {
+		case DropNewest:
+			q.dropped.Add(1)
+			q.mu.Unlock()
+			return nil
+		case DropOldest:
+			q.dropped.Add(1)
+			q.pop()
+			continue
+		case DropBelow:
+			if n, ok := severity(r.Level); ok && n < q.level {
+				q.dropped.Add(1)
+				q.mu.Unlock()
+				return nil
+			}
+		}
+		q.full.Wait()
+	}
+	if q.closed {
+		// the worker is gone, write synchronously
+		q.mu.Unlock()
+		return l.write(1, r)
+	}
+
+	q.ring[(q.head+q.n)%len(q.ring)] = asyncEntry{l, r}
+	q.n++
+	if q.idle == nil {
+		q.idle = make(chan struct{})
+	}
+	q.ready.Signal()
+	q.mu.Unlock()
+	return nil
+}
+
+// pop removes the oldest entry from the queue and returns it.
+func (q *asyncQueue) pop() asyncEntry {
+	e := q.ring[q.head]
+	q.ring[q.head] = asyncEntry{}
+	q.head = (q.head + 1) % len(q.ring)
+	q.n--
+	return e
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	var batch []asyncEntry
+	q.mu.Lock()
+	for {
+		for q.n == 0 && !q.closed {
+			q.ready.Wait()
+		}
+		if q.n == 0 {
+			q.mu.Unlock()
+			return
+		}
+		batch = batch[:0]
+		for q.n > 0 {
+			batch = append(batch, q.pop())
+		}
+		q.full.Broadcast()
+		q.mu.Unlock()
+
+		for i, e := range batch {
+			e.l.write(1, e.r)
+			batch[i] = asyncEntry{}
+		}
+
+		q.mu.Lock()
+		if q.n == 0 && q.idle != nil {
+			close(q.idle)
+			q.idle = nil
+		}
+	}
+}
+
+// flush waits until the queued records are written.
+func (q *asyncQueue) flush(ctx context.Context) error {
+	q.mu.Lock()
+	idle := q.idle
+	q.mu.Unlock()
+
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close writes the queued records and stops the worker.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.ready.Signal()
+	q.full.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// SetAsync makes the logger queue the records and write them in a
+// background goroutine, so slow outputs do not block the callers.
+// Loggers derived with WithName or With afterwards share the queue.
+// The registered children of the logger, see Get, write synchronously
+// unless SetAsync is called for them too.
+//
+// The caller reported by outputs which are not a RecordOutputter is
+// meaningless in the asynchronous mode, use Record.PC instead.
+func (l *Multi) SetAsync(opts AsyncOptions) {
+	dropped := l.dropped.Load()
+	if dropped == nil {
+		dropped = new(atomic.Uint64)
+		l.dropped.Store(dropped)
+	}
+	if old := l.async.Swap(newAsyncQueue(opts, dropped)); old != nil {
+		old.close()
+	}
+}
+
+// Dropped returns the number of records discarded by the overflow
+// policy of the asynchronous mode, including after Close.
+func (l *Multi) Dropped() uint64 {
+	if dropped := l.dropped.Load(); dropped != nil {
+		return dropped.Load()
+	}
+	return 0
+}
+
+// Flush waits until the records queued in the asynchronous mode are
+// written, or ctx is done.
+func (l *Multi) Flush(ctx context.Context) error {
+	if q := l.async.Load(); q != nil {
+		return q.flush(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type slowOutput struct {
+	mu    sync.Mutex
+	delay time.Duration
+	lines []string
+}
+
+func (o *slowOutput) Output(calldepth int, s string) error {
+	time.Sleep(o.delay)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lines = append(o.lines, s)
+	return nil
+}
+
+func (o *slowOutput) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.lines)
+}
+
+func TestAsync(t *testing.T) {
+	out := &slowOutput{delay: time.Millisecond}
+	l := New("main: ", out)
+	l.SetAsync(AsyncOptions{Size: 4})
+
+	for i := 0; i < 10; i++ {
+		l.Infof("line %d", i)
+	}
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 10, out.len(); want != got {
+		t.Fatalf("async logger should output %d lines, got %d", want, got)
+	}
+	if want, got := "INFO main: line 9", out.lines[9]; want != got {
+		t.Errorf("async logger output should match %q is %q", want, got)
+	}
+
+	out.delay = 0
+	l.Close()
+	if l.Dropped() != 0 {
+		t.Errorf("blocking logger should not drop records")
+	}
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	out := &slowOutput{delay: 20 * time.Millisecond}
+	l := New("main: ", out)
+	l.SetAsync(AsyncOptions{Size: 2, Overflow: DropBelow, Level: Lerror})
+
+	for i := 0; i < 10; i++ {
+		l.Info("dropped")
+	}
+	l.Error("kept")
+	if l.Dropped() == 0 {
+		t.Errorf("async logger should drop records below error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := l.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("flush should time out, got %v", err)
+	}
+
+	dropped := l.Dropped()
+	l.Close()
+	if l.Dropped() != dropped {
+		t.Errorf("closed logger should keep the drops, got %d want %d", l.Dropped(), dropped)
+	}
+	if got := out.lines[len(out.lines)-1]; got != "ERROR main: kept" {
+		t.Errorf("async logger should write queued records on close, got %q", got)
+	}
+}
+
+func TestAsyncCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", NewSlogOutput(newTestJSONHandler(&buf)))
+	l.SetAsync(AsyncOptions{})
+	l.Warn("This is warn")
+	l.Close()
+	if want, got := `{"level":"WARN","line":89,"msg":"This is warn","logger":"main"}`+"\n", buf.String(); want != got {
+		t.Errorf("async output should match %q is %q", want, got)
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	out := &slowOutput{delay: 20 * time.Millisecond}
+	l := New("main: ", out)
+	l.SetAsync(AsyncOptions{Size: 3, Overflow: DropOldest})
+
+	for i := 0; i < 10; i++ {
+		l.Infof("line %d", i)
+	}
+	l.Close()
+	dropped := l.Dropped()
+	if want, got := uint64(10), uint64(len(out.lines))+dropped; want != got {
+		t.Errorf("async logger should write or drop %d lines, got %d", want, got)
+	}
+	if dropped == 0 {
+		t.Errorf("async logger should drop the oldest records")
+	}
+	for i, want := range []string{"INFO main: line 7", "INFO main: line 8", "INFO main: line 9"} {
+		if got := out.lines[len(out.lines)-3+i]; want != got {
+			t.Errorf("async logger should keep the newest records, %q is %q", want, got)
+		}
+	}
+}
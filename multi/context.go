@@ -0,0 +1,133 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+	userKey
+	traceKey
+)
+
+// ContextFunc returns the fields to be logged for a context.
+type ContextFunc func(ctx context.Context) []Field
+
+var (
+	ctxMu    sync.RWMutex
+	ctxFuncs = []ContextFunc{builtinContext}
+)
+
+// RegisterContext adds fn to the functions called for every message
+// logged with a context.
+func RegisterContext(fn ContextFunc) {
+	ctxMu.Lock()
+	defer ctxMu.Unlock()
+
+	ctxFuncs = append(ctxFuncs, fn)
+}
+
+// RegisterContextKey logs the value stored in the context under key
+// as the field name.
+func RegisterContextKey(name string, key any) {
+	RegisterContext(func(ctx context.Context) []Field {
+		if v := ctx.Value(key); v != nil {
+			return []Field{{name, v}}
+		}
+		return nil
+	})
+}
+
+func contextFields(ctx context.Context) (fields []Field) {
+	if ctx == nil {
+		return nil
+	}
+
+	ctxMu.RLock()
+	defer ctxMu.RUnlock()
+
+	for _, fn := range ctxFuncs {
+		fields = append(fields, fn(ctx)...)
+	}
+	return
+}
+
+// NewContext returns a copy of ctx carrying the logger l.
+func NewContext(ctx context.Context, l *Multi) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger carried by ctx, or the standard
+// logger if there is none.
+func FromContext(ctx context.Context) *Multi {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey).(*Multi); ok {
+			return l
+		}
+	}
+	return global
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, which is
+// logged as the field "request_id".
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// WithUser returns a copy of ctx carrying the user, which is logged as
+// the field "user".
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+type traceParent struct {
+	traceID string
+	spanID  string
+}
+
+// WithTraceparent returns a copy of ctx carrying the IDs of a W3C
+// traceparent header like "00-<trace-id>-<parent-id>-<flags>", which
+// are logged as the fields "trace_id" and "span_id".
+func WithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" ||
+		!isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) ||
+		strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return ctx, fmt.Errorf("invalid traceparent %q", traceparent)
+	}
+	return context.WithValue(ctx, traceKey, traceParent{parts[1], parts[2]}), nil
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func builtinContext(ctx context.Context) (fields []Field) {
+	if v, ok := ctx.Value(requestIDKey).(string); ok {
+		fields = append(fields, Field{"request_id", v})
+	}
+	if v, ok := ctx.Value(userKey).(string); ok {
+		fields = append(fields, Field{"user", v})
+	}
+	if v, ok := ctx.Value(traceKey).(traceParent); ok {
+		fields = append(fields, Field{"trace_id", v.traceID}, Field{"span_id", v.spanID})
+	}
+	return
+}
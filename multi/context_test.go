@@ -0,0 +1,40 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func init() {
+	RegisterContextKey("tenant", tenantKey{})
+}
+
+func TestContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", log.New(&buf, "", 0))
+
+	if FromContext(context.Background()) != Global() {
+		t.Errorf("context without logger should return the standard logger")
+	}
+
+	ctx := NewContext(context.Background(), l)
+	ctx = WithRequestID(ctx, "r-1")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	ctx, err := WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	InfoCtx(ctx, "This is info")
+	if want, got := "INFO main: This is info request_id=r-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 tenant=acme\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+
+	if _, err := WithTraceparent(ctx, "00-00000000000000000000000000000000-00f067aa0ba902b7-01"); err == nil {
+		t.Errorf("traceparent with zero trace id should be invalid")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const badKey = "!BADKEY"
+
+// Field is a typed key/value pair carried along with a log message.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// String returns the field as key=value. The value is quoted if it
+// contains spaces, quotes or equal signs.
+func (f Field) String() string {
+	return f.Key + "=" + quoteValue(fmt.Sprint(f.Value))
+}
+
+func quoteValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Fields converts alternating key, value arguments to fields. An
+// argument which is already a Field is used as is. A value without
+// a string key is stored with the key "!BADKEY".
+func Fields(kv ...any) []Field {
+	fields := make([]Field, 0, len(kv)/2+1)
+	for i := 0; i < len(kv); i++ {
+		switch k := kv[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i+1 >= len(kv) {
+				fields = append(fields, Field{badKey, k})
+				break
+			}
+			fields = append(fields, Field{k, kv[i+1]})
+			i++
+		default:
+			fields = append(fields, Field{badKey, k})
+		}
+	}
+	return fields
+}
+
+// appendFields appends fields to msg as key=value text, keeping the
+// trailing newline of msg, if any, at the end.
+func appendFields(msg string, fields ...[]Field) string {
+	n := 0
+	for _, ff := range fields {
+		n += len(ff)
+	}
+	if n == 0 {
+		return msg
+	}
+
+	var sb strings.Builder
+	nl := strings.HasSuffix(msg, "\n")
+	sb.WriteString(strings.TrimSuffix(msg, "\n"))
+	for _, ff := range fields {
+		for _, f := range ff {
+			sb.WriteByte(' ')
+			sb.WriteString(f.String())
+		}
+	}
+	if nl {
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
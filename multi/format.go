@@ -0,0 +1,229 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// text returns the record as the line handed to an Outputter, with the
+// fields as key=value text.
+func (r *Record) text() string {
+	return r.Level + r.Name + appendFields(r.Message, r.Fields)
+}
+
+// Caller returns the file and line of the caller, or "???" and 0 if
+// the caller is unknown.
+func (r *Record) Caller() (file string, line int) {
+	if r.PC == 0 {
+		return "???", 0
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	if frame.File == "" {
+		return "???", 0
+	}
+	return frame.File, frame.Line
+}
+
+// loggerName returns the name of a Multi without the trailing colon
+// and spaces, e.g. "main" for "main: ".
+func loggerName(name string) string {
+	return strings.TrimSuffix(strings.TrimSpace(name), ":")
+}
+
+// Formatter encodes a record, e.g. as a line of text or a JSON object.
+type Formatter interface {
+	// Format appends the encoded record, ending with a newline, to b.
+	Format(b []byte, r *Record) []byte
+}
+
+// TextFormatter encodes the record like a log.Logger with the prefix
+// flag log.Lmsgprefix, followed by the fields as key=value text.
+type TextFormatter struct {
+	Flags int // log.Ldate, log.Ltime, log.Lshortfile, ...
+}
+
+func (f *TextFormatter) Format(b []byte, r *Record) []byte {
+	if f.Flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		t := r.Time
+		if f.Flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if f.Flags&log.Ldate != 0 {
+			b = t.AppendFormat(b, "2006/01/02 ")
+		}
+		if f.Flags&log.Lmicroseconds != 0 {
+			b = t.AppendFormat(b, "15:04:05.000000 ")
+		} else if f.Flags&log.Ltime != 0 {
+			b = t.AppendFormat(b, "15:04:05 ")
+		}
+	}
+	if f.Flags&(log.Lshortfile|log.Llongfile) != 0 {
+		file, line := r.Caller()
+		if f.Flags&log.Lshortfile != 0 {
+			file = file[strings.LastIndexByte(file, '/')+1:]
+		}
+		b = append(b, file...)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, int64(line), 10)
+		b = append(b, ": "...)
+	}
+	b = append(b, r.Level...)
+	b = append(b, r.Name...)
+	b = append(b, appendFields(r.Message, r.Fields)...)
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// JSONFormatter encodes the record as a JSON object with the keys
+// "time", "level", "logger", "caller" and "msg" followed by the fields.
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Format(b []byte, r *Record) []byte {
+	b = append(b, `{"time":`...)
+	b = appendJSON(b, r.Time.Format(time.RFC3339Nano))
+	if level := strings.TrimSpace(r.Level); level != "" {
+		b = append(b, `,"level":`...)
+		b = appendJSON(b, level)
+	}
+	if name := loggerName(r.Name); name != "" {
+		b = append(b, `,"logger":`...)
+		b = appendJSON(b, name)
+	}
+	if r.PC != 0 {
+		file, line := r.Caller()
+		b = append(b, `,"caller":`...)
+		b = appendJSON(b, file+":"+strconv.Itoa(line))
+	}
+	b = append(b, `,"msg":`...)
+	b = appendJSON(b, strings.TrimSuffix(r.Message, "\n"))
+	for _, field := range r.Fields {
+		b = append(b, ',')
+		b = appendJSON(b, field.Key)
+		b = append(b, ':')
+		b = appendJSON(b, field.Value)
+	}
+	return append(b, "}\n"...)
+}
+
+func appendJSON(b []byte, v any) []byte {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return append(b, data...)
+}
+
+// LogfmtFormatter encodes the record as logfmt key=value pairs with the
+// keys "time", "level", "logger", "caller" and "msg" followed by the
+// fields.
+type LogfmtFormatter struct{}
+
+func (f *LogfmtFormatter) Format(b []byte, r *Record) []byte {
+	b = append(b, "time="...)
+	b = r.Time.AppendFormat(b, time.RFC3339Nano)
+	if level := strings.TrimSpace(r.Level); level != "" {
+		b = append(b, " level="...)
+		b = append(b, quoteValue(level)...)
+	}
+	if name := loggerName(r.Name); name != "" {
+		b = append(b, " logger="...)
+		b = append(b, quoteValue(name)...)
+	}
+	if r.PC != 0 {
+		file, line := r.Caller()
+		b = append(b, " caller="...)
+		b = append(b, quoteValue(file+":"+strconv.Itoa(line))...)
+	}
+	b = append(b, " msg="...)
+	b = append(b, quoteValue(strings.TrimSuffix(r.Message, "\n"))...)
+	for _, field := range r.Fields {
+		b = append(b, ' ')
+		b = append(b, field.String()...)
+	}
+	return append(b, '\n')
+}
+
+// LevelWriter is implemented by writers which handle the records by
+// level, e.g. flushing the severe ones at once.
+type LevelWriter interface {
+	WriteLevel(level string, p []byte) (n int, err error)
+}
+
+// Writer is an output which encodes the records with a Formatter and
+// writes each of them to an io.Writer with a single Write, or
+// WriteLevel if the io.Writer is a LevelWriter.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	f   Formatter
+	buf []byte
+}
+
+// NewWriter creates an output writing the records encoded by f to w.
+func NewWriter(w io.Writer, f Formatter) *Writer {
+	return &Writer{w: w, f: f}
+}
+
+// Output writes s as the message of a record without level.
+func (w *Writer) Output(calldepth int, s string) error {
+	var pcs [1]uintptr
+	runtime.Callers(calldepth+1, pcs[:])
+	return w.OutputRecord(1+calldepth, &Record{Time: time.Now(), Message: s, PC: pcs[0]})
+}
+
+func (w *Writer) OutputRecord(calldepth int, r *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = w.f.Format(w.buf[:0], r)
+	var err error
+	if lw, ok := w.w.(LevelWriter); ok {
+		_, err = lw.WriteLevel(r.Level, w.buf)
+	} else {
+		_, err = w.w.Write(w.buf)
+	}
+	return err
+}
+
+// Tee is an output which writes to all of its outputs. The error is
+// the first one returned by the outputs.
+type Tee []Outputter
+
+func (t Tee) Output(calldepth int, s string) (err error) {
+	for _, out := range t {
+		if e := out.Output(1+calldepth, s); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+func (t Tee) OutputRecord(calldepth int, r *Record) (err error) {
+	for _, out := range t {
+		var e error
+		if ro, ok := out.(RecordOutputter); ok {
+			e = ro.OutputRecord(1+calldepth, r)
+		} else {
+			e = out.Output(1+calldepth, r.text())
+		}
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
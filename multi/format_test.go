@@ -0,0 +1,50 @@
+package multi
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+)
+
+func testRecord() *Record {
+	return &Record{
+		Time:    time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   Lwarn,
+		Name:    "main: ",
+		Message: "disk full",
+		Fields:  []Field{{"path", "/var/log"}, {"err", errors.New("no space")}},
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	for _, tt := range []struct {
+		f    Formatter
+		want string
+	}{
+		{&TextFormatter{Flags: log.LstdFlags | log.LUTC}, "2022/01/02 03:04:05 WARN main: disk full path=/var/log err=\"no space\"\n"},
+		{&JSONFormatter{}, `{"time":"2022-01-02T03:04:05Z","level":"WARN","logger":"main","msg":"disk full","path":"/var/log","err":"no space"}` + "\n"},
+		{&LogfmtFormatter{}, `time=2022-01-02T03:04:05Z level=WARN logger=main msg="disk full" path=/var/log err="no space"` + "\n"},
+	} {
+		if got := string(tt.f.Format(nil, testRecord())); tt.want != got {
+			t.Errorf("%T should match %q is %q", tt.f, tt.want, got)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var text, json bytes.Buffer
+	l := New("main: ", Tee{
+		NewWriter(&text, &TextFormatter{Flags: log.Lshortfile}),
+		NewWriter(&json, &LogfmtFormatter{}),
+	})
+	l.Errorw("failed", "code", 7)
+	if want, got := "format_test.go:42: ERROR main: failed code=7\n", text.String(); want != got {
+		t.Errorf("text output should match %q is %q", want, got)
+	}
+	if !bytes.Contains(json.Bytes(), []byte(`level=ERROR logger=main caller=`)) ||
+		!bytes.HasSuffix(json.Bytes(), []byte("format_test.go:42 msg=failed code=7\n")) {
+		t.Errorf("logfmt output is %q", json.String())
+	}
+}
@@ -4,6 +4,7 @@
 package multi
 
 import (
+	"context"
 	"os"
 )
 
@@ -15,6 +16,28 @@ func Redirect(l *Multi) {
 	global.CopyFrom(l)
 }
 
+// With returns a logger which adds the key/value fields to every
+// message of the standard logger.
+func With(kv ...any) *Multi {
+	return global.With(kv...)
+}
+
+// SetLevel sets the minimum level of the standard logger.
+func SetLevel(level string) {
+	global.SetLevel(level)
+}
+
+// Level returns the minimum level of the standard logger.
+func Level() string {
+	return global.Level()
+}
+
+// Enabled reports whether the standard logger would output a message
+// at the level.
+func Enabled(level string) bool {
+	return global.Enabled(level)
+}
+
 func Restore() {
 	global.Close()
 	global.CopyFrom(Default())
@@ -36,6 +59,14 @@ func Traceln(v ...any) {
 	global.Loutputln(1, Ltrace, v...)
 }
 
+func Tracew(msg string, kv ...any) {
+	global.Loutputw(1, Ltrace, msg, kv...)
+}
+
+func TraceCtx(ctx context.Context, v ...any) {
+	FromContext(ctx).LoutputCtx(1, ctx, Ltrace, v...)
+}
+
 func Debug(v ...any) {
 	global.Loutput(1, Ldebug, v...)
 }
@@ -48,6 +79,14 @@ func Debugln(v ...any) {
 	global.Loutputln(1, Ldebug, v...)
 }
 
+func Debugw(msg string, kv ...any) {
+	global.Loutputw(1, Ldebug, msg, kv...)
+}
+
+func DebugCtx(ctx context.Context, v ...any) {
+	FromContext(ctx).LoutputCtx(1, ctx, Ldebug, v...)
+}
+
 func Info(v ...any) {
 	global.Loutput(1, Linfo, v...)
 }
@@ -60,6 +99,14 @@ func Infoln(v ...any) {
 	global.Loutputln(1, Linfo, v...)
 }
 
+func Infow(msg string, kv ...any) {
+	global.Loutputw(1, Linfo, msg, kv...)
+}
+
+func InfoCtx(ctx context.Context, v ...any) {
+	FromContext(ctx).LoutputCtx(1, ctx, Linfo, v...)
+}
+
 func Warn(v ...any) {
 	global.Loutput(1, Lwarn, v...)
 }
@@ -72,6 +119,14 @@ func Warnln(v ...any) {
 	global.Loutputln(1, Lwarn, v...)
 }
 
+func Warnw(msg string, kv ...any) {
+	global.Loutputw(1, Lwarn, msg, kv...)
+}
+
+func WarnCtx(ctx context.Context, v ...any) {
+	FromContext(ctx).LoutputCtx(1, ctx, Lwarn, v...)
+}
+
 func Error(v ...any) {
 	global.Loutput(1, Lerror, v...)
 }
@@ -84,6 +139,14 @@ func Errorln(v ...any) {
 	global.Loutputln(1, Lerror, v...)
 }
 
+func Errorw(msg string, kv ...any) {
+	global.Loutputw(1, Lerror, msg, kv...)
+}
+
+func ErrorCtx(ctx context.Context, v ...any) {
+	FromContext(ctx).LoutputCtx(1, ctx, Lerror, v...)
+}
+
 func Print(v ...any) {
 	global.Loutput(1, Linfo, v...)
 }
@@ -113,3 +176,16 @@ func Fatalln(v ...any) {
 	global.Close()
 	os.Exit(1)
 }
+
+func Fatalw(msg string, kv ...any) {
+	global.Loutputw(1, Lfatal, msg, kv...)
+	global.Close()
+	os.Exit(1)
+}
+
+func FatalCtx(ctx context.Context, v ...any) {
+	l := FromContext(ctx)
+	l.LoutputCtx(1, ctx, Lfatal, v...)
+	l.Close()
+	os.Exit(1)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// handlerCalldepth skips slog.Logger.log and the slog.Logger method
+// called by the user, so the caller reported by the outputs is the
+// caller of the slog.Logger. It is used if the record has no PC.
+const handlerCalldepth = 3
+
+// Handler is a slog.Handler which writes records through a Multi,
+// so slog.New(NewHandler(l)) uses the outputs configured for l.
+//
+// The caller and the time are taken from the slog.Record. Outputs which
+// are not a RecordOutputter compute the caller from the call stack, so
+// it is only correct if the record is handled in the goroutine which
+// logged it.
+type Handler struct {
+	l      *Multi
+	fields []Field
+	prefix string // groups joined with '.'
+}
+
+// NewHandler creates a slog.Handler writing to l.
+func NewHandler(l *Multi) *Handler {
+	return &Handler{l: l}
+}
+
+// SlogLevel returns the level string matching the slog level.
+func SlogLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelDebug:
+		return Ltrace
+	case level < slog.LevelInfo:
+		return Ldebug
+	case level < slog.LevelWarn:
+		return Linfo
+	case level < slog.LevelError:
+		return Lwarn
+	case level < slog.LevelError+4:
+		return Lerror
+	}
+	return Lfatal
+}
+
+// LevelToSlog returns the slog level matching the level string.
+// Levels added by RegisterLevel are mapped like the closest lower
+// level, unknown levels to slog.LevelInfo.
+func LevelToSlog(level string) slog.Level {
+	switch level {
+	case Ltrace:
+		return slog.LevelDebug - 4
+	case Ldebug:
+		return slog.LevelDebug
+	case Lwarn:
+		return slog.LevelWarn
+	case Lerror:
+		return slog.LevelError
+	case Lfatal:
+		return slog.LevelError + 4
+	}
+	if lower := lowerLevels(level); len(lower) > 0 {
+		return LevelToSlog(lower[0])
+	}
+	return slog.LevelInfo
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.Enabled(SlogLevel(level))
+}
+
+// Handle writes r with the fields registered for ctx, see RegisterContext.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, contextFields(ctx)...)
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	if r.PC == 0 {
+		return h.l.output(handlerCalldepth, SlogLevel(r.Level), r.Message, fields)
+	}
+
+	all := make([]Field, 0, len(h.l.fields)+len(fields))
+	all = append(all, h.l.fields...)
+	all = append(all, fields...)
+	return h.l.outputRecord(callerDepth(r.PC), &Record{
+		Time:    r.Time,
+		Level:   SlogLevel(r.Level),
+		Name:    h.l.name,
+		Message: r.Message,
+		Fields:  all,
+		PC:      r.PC,
+	})
+}
+
+// callerDepth returns the calldepth of pc for the caller of callerDepth,
+// or handlerCalldepth if pc is not on the call stack.
+func callerDepth(pc uintptr) int {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	for i, p := range pcs[:n] {
+		if p == pc {
+			return i
+		}
+	}
+	return handlerCalldepth
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &Handler{l: h.l, fields: fields, prefix: h.prefix}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{l: h.l, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// appendAttr flattens a to fields, qualifying the keys of groups
+// with dots.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{prefix + a.Key, a.Value.Any()})
+}
+
+// SlogOutput is an Outputter which passes the records to a slog.Handler,
+// e.g. slog.NewJSONHandler. The name of the Multi is added as the
+// "logger" attribute.
+type SlogOutput struct {
+	h slog.Handler
+}
+
+// NewSlogOutput creates an Outputter writing to the handler h.
+func NewSlogOutput(h slog.Handler) *SlogOutput {
+	return &SlogOutput{h: h}
+}
+
+// Output logs s at level Info.
+func (o *SlogOutput) Output(calldepth int, s string) error {
+	return o.OutputRecord(1+calldepth, &Record{Level: Linfo, Message: s})
+}
+
+func (o *SlogOutput) OutputRecord(calldepth int, r *Record) error {
+	ctx := context.Background()
+	level := LevelToSlog(r.Level)
+	if !o.h.Enabled(ctx, level) {
+		return nil
+	}
+
+	pc := r.PC
+	if pc == 0 {
+		var pcs [1]uintptr
+		runtime.Callers(calldepth+1, pcs[:])
+		pc = pcs[0]
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	sr := slog.NewRecord(t, level, strings.TrimSuffix(r.Message, "\n"), pc)
+	if name := loggerName(r.Name); name != "" {
+		sr.AddAttrs(slog.String("logger", name))
+	}
+	for _, f := range r.Fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	return o.h.Handle(ctx, sr)
+}
@@ -0,0 +1,68 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// wrapHandler is a handler wrapping another one, which adds a frame
+// between the slog.Logger and the Handler.
+type wrapHandler struct {
+	slog.Handler
+}
+
+func (h wrapHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestHandlerCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", log.New(&buf, "", log.Lshortfile))
+
+	sl := slog.New(wrapHandler{NewHandler(l)})
+	_, _, line, _ := runtime.Caller(0)
+	sl.Info("wrapped")
+	if want, got := fmt.Sprintf("handler_caller_test.go:%d: INFO main: wrapped\n", line+1), buf.String(); want != got {
+		t.Errorf("handler output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	flags, w := log.Flags(), log.Writer()
+	defer func(d *slog.Logger) {
+		slog.SetDefault(d)
+		log.SetFlags(flags)
+		log.SetOutput(w)
+	}(slog.Default())
+	log.SetFlags(log.Lshortfile)
+	slog.SetDefault(slog.New(NewHandler(l)))
+	_, _, line, _ = runtime.Caller(0)
+	log.Print("std")
+	if want, got := fmt.Sprintf("handler_caller_test.go:%d: INFO main: std\n", line+1), buf.String(); want != got {
+		t.Errorf("handler output should match %q is %q", want, got)
+	}
+}
+
+func TestHandlerRecord(t *testing.T) {
+	var out recordOutput
+	l := New("main: ", &out).With("request", 42)
+
+	r := slog.NewRecord(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelWarn, "slow", 1234)
+	r.AddAttrs(slog.Int("ms", 120))
+	if err := NewHandler(l).Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.records) != 1 {
+		t.Fatalf("handler should output 1 record, got %d", len(out.records))
+	}
+	got := out.records[0]
+	if !got.Time.Equal(r.Time) || got.PC != r.PC || got.Level != Lwarn ||
+		len(got.Fields) != 2 || got.Fields[0].Key != "request" || got.Fields[1].Key != "ms" {
+		t.Errorf("handler record should keep time, PC and fields, got %+v", got)
+	}
+}
@@ -0,0 +1,84 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", log.New(&buf, "", log.Lshortfile|log.Lmsgprefix))
+	l.SetLevel(Ldebug)
+	sl := slog.New(NewHandler(l))
+
+	sl.Info("This is info", "user", "bob")
+	if want, got := "handler_test.go:18: INFO main: This is info user=bob\n", buf.String(); want != got {
+		t.Errorf("handler output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	sl.With("request", 42).WithGroup("db").Warn("slow", "ms", 120, slog.Group("conn", "id", 3))
+	if want, got := "handler_test.go:24: WARN main: slow request=42 db.ms=120 db.conn.id=3\n", buf.String(); want != got {
+		t.Errorf("handler output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	sl.Log(context.Background(), slog.LevelDebug-4, "This is trace")
+	if buf.Len() != 0 {
+		t.Errorf("handler should not output %q", buf.String())
+	}
+
+	for level, want := range map[slog.Level]string{
+		slog.LevelDebug - 1: Ltrace,
+		slog.LevelDebug:     Ldebug,
+		slog.LevelInfo:      Linfo,
+		slog.LevelWarn:      Lwarn,
+		slog.LevelError:     Lerror,
+		slog.LevelError + 4: Lfatal,
+	} {
+		if got := SlogLevel(level); want != got {
+			t.Errorf("slog level %v should map to %q is %q", level, want, got)
+		}
+	}
+}
+
+func TestSlogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", NewSlogOutput(newTestJSONHandler(&buf)))
+
+	l.Errorw("failed", "err", "timeout")
+	if want, got := `{"level":"ERROR","line":53,"msg":"failed","logger":"main","err":"timeout"}`+"\n", buf.String(); want != got {
+		t.Errorf("slog output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	Redirect(l)
+	defer Restore()
+	Traceln("This is trace")
+	if want, got := `{"level":"DEBUG-4","line":61,"msg":"This is trace","logger":"root"}`+"\n", buf.String(); want != got {
+		t.Errorf("slog output should match %q is %q", want, got)
+	}
+}
+
+// newTestJSONHandler returns a JSON handler without time, which reports
+// the line number of the source.
+func newTestJSONHandler(w io.Writer) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug - 4,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				src := a.Value.Any().(*slog.Source)
+				return slog.Int("line", src.Line)
+			}
+			return a
+		},
+	})
+}
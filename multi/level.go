@@ -0,0 +1,166 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// noLevel is the minimum level of a logger which inherits the level of
+// its parent.
+const noLevel = math.MinInt32
+
+// LevelDef describes a level.
+type LevelDef struct {
+	Level    string // the string written before the name, e.g. "INFO "
+	Severity int32  // the ordering of the levels, higher is more severe
+	Color    string // the ANSI escape sequence used by consoles, if any
+}
+
+var builtinLevels = []LevelDef{
+	{Ltrace, 10, "\033[35m"},
+	{Ldebug, 20, "\033[32m"},
+	{Linfo, 30, ""},
+	{Lwarn, 40, "\033[33m"},
+	{Lerror, 50, "\033[31m"},
+	{Lfatal, 60, "\033[31m"},
+}
+
+// levelTable is replaced as a whole on registration, so it can be read
+// without locking.
+type levelTable struct {
+	defs  map[string]LevelDef
+	names map[string]string // lower case names and aliases to levels
+}
+
+var (
+	levelMu sync.Mutex
+	levels  = newLevels() // initialized before RegisterLevel in package variables
+)
+
+func newLevels() *atomic.Pointer[levelTable] {
+	t := &levelTable{
+		defs: make(map[string]LevelDef),
+		names: map[string]string{
+			"trace":   Ltrace,
+			"debug":   Ldebug,
+			"warning": Lwarn,
+			"err":     Lerror,
+		},
+	}
+	for _, def := range builtinLevels {
+		t.add(def)
+	}
+	p := new(atomic.Pointer[levelTable])
+	p.Store(t)
+	return p
+}
+
+func (t *levelTable) add(def LevelDef) {
+	t.defs[def.Level] = def
+	t.names[strings.ToLower(strings.TrimSpace(def.Level))] = def.Level
+}
+
+// RegisterLevel adds the level with the display name, e.g. "NOTICE",
+// and returns the level string, e.g. "NOTICE ", to be passed to
+// SetOutput and Loutput. Levels should be registered at init time,
+// since LevelStrings is not guarded against concurrent access.
+func RegisterLevel(name string, severity int32, color string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" || severity == noLevel {
+		return "", errors.New("invalid level " + name)
+	}
+	level := name + " "
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	old := levels.Load()
+	if _, ok := old.names[strings.ToLower(name)]; ok {
+		return "", errors.New("duplicate level " + name)
+	}
+	t := &levelTable{
+		defs:  make(map[string]LevelDef, len(old.defs)+1),
+		names: make(map[string]string, len(old.names)+1),
+	}
+	for k, v := range old.defs {
+		t.defs[k] = v
+	}
+	for k, v := range old.names {
+		t.names[k] = v
+	}
+	t.add(LevelDef{level, severity, color})
+	levels.Store(t)
+
+	LevelStrings = append(LevelStrings, level)
+	sort.SliceStable(LevelStrings, func(i, j int) bool {
+		return t.defs[LevelStrings[i]].Severity < t.defs[LevelStrings[j]].Severity
+	})
+	return level, nil
+}
+
+// LookupLevel returns the definition of the level.
+func LookupLevel(level string) (LevelDef, bool) {
+	def, ok := levels.Load().defs[level]
+	return def, ok
+}
+
+// ParseLevel returns the level string of a level name like "info" or
+// "Warning", ignoring case and spaces. Level strings are returned as is.
+func ParseLevel(s string) (string, bool) {
+	t := levels.Load()
+	if _, ok := t.defs[s]; ok {
+		return s, true
+	}
+	level, ok := t.names[strings.ToLower(strings.TrimSpace(s))]
+	return level, ok
+}
+
+// severity returns the severity of the level, or false if the level is
+// unknown.
+func severity(level string) (int32, bool) {
+	def, ok := levels.Load().defs[level]
+	return def.Severity, ok
+}
+
+// levelOf returns the least severe level at or above the severity.
+func levelOf(n int32) string {
+	t := levels.Load()
+	level, found := "", false
+	for _, def := range t.defs {
+		if def.Severity >= n && (!found || def.Severity < t.defs[level].Severity) {
+			level, found = def.Level, true
+		}
+	}
+	return level
+}
+
+// lowerLevels returns the levels less severe than the registered level
+// starting with the closest one, or nil for the built-in levels.
+func lowerLevels(level string) (lower []string) {
+	t := levels.Load()
+	def, ok := t.defs[level]
+	if !ok {
+		return nil
+	}
+	for _, b := range builtinLevels {
+		if b.Level == level {
+			return nil
+		}
+	}
+	for _, d := range t.defs {
+		if d.Severity < def.Severity {
+			lower = append(lower, d.Level)
+		}
+	}
+	sort.Slice(lower, func(i, j int) bool {
+		return t.defs[lower[i]].Severity > t.defs[lower[j]].Severity
+	})
+	return
+}
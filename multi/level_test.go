@@ -0,0 +1,44 @@
+package multi
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"testing"
+)
+
+var Lnotice, _ = RegisterLevel("notice", 35, "\033[36m")
+
+func TestRegisterLevel(t *testing.T) {
+	if want, got := "NOTICE ", Lnotice; want != got {
+		t.Fatalf("registered level should be %q is %q", want, got)
+	}
+	if _, err := RegisterLevel("Notice", 36, ""); err == nil {
+		t.Errorf("duplicate level should not be registered")
+	}
+	if level, ok := ParseLevel(" Notice"); !ok || level != Lnotice {
+		t.Errorf("level name should be parsed, got %q", level)
+	}
+	if want, got := []string{Ltrace, Ldebug, Linfo, Lnotice, Lwarn, Lerror, Lfatal}, LevelStrings; len(want) != len(got) || got[3] != Lnotice {
+		t.Errorf("levels should be ordered by severity %q is %q", want, got)
+	}
+
+	var buf bytes.Buffer
+	l := Omitter("main: ")
+	l.SetOutput(Linfo, log.New(&buf, "", 0))
+	l.Loutput(0, Lnotice, "This is notice")
+	if want, got := "NOTICE main: This is notice\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	l.SetLevel(Lnotice)
+	l.Info("This is info")
+	if buf.Len() != 0 || !l.Enabled(Lnotice) || l.Level() != Lnotice {
+		t.Errorf("logger level %q should disable info, got %q", l.Level(), buf.String())
+	}
+
+	if want, got := slog.LevelInfo, LevelToSlog(Lnotice); want != got {
+		t.Errorf("slog level should be %v is %v", want, got)
+	}
+}
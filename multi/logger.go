@@ -3,10 +3,31 @@
 
 package multi
 
+import "time"
+
 type Outputter interface {
 	Output(calldepth int, s string) error
 }
 
+// Record is a log entry with its fields kept apart from the message.
+type Record struct {
+	Time    time.Time
+	Level   string
+	Name    string
+	Message string
+	Fields  []Field
+
+	// PC is the program counter of the caller, zero if unknown.
+	PC uintptr
+}
+
+// RecordOutputter is implemented by outputs which encode the fields
+// themselves. Multi prefers OutputRecord to Output for such outputs,
+// other outputs get the fields as key=value text.
+type RecordOutputter interface {
+	OutputRecord(calldepth int, r *Record) error
+}
+
 type Logger interface {
 	// Error is equivalent to Print() and logs the message at level Error.
 	Error(v ...any)
@@ -4,12 +4,16 @@
 package multi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -25,14 +29,22 @@ const (
 	Lfatal string = "FATAL "
 )
 
+// LevelStrings lists the levels ordered by severity, including the
+// levels added by RegisterLevel.
 var LevelStrings = []string{Ltrace, Ldebug, Linfo, Lwarn, Lerror, Lfatal}
 
 var errOutput = errors.New("No output")
 
 type Multi struct {
-	mu   sync.Mutex
-	name string
-	logs map[string]Outputter
+	mu      sync.Mutex
+	name    string
+	logs    map[string]Outputter
+	fields  []Field
+	level   int32 // severity of the minimum level, accessed atomically; noLevel inherits from parent
+	parent  *Multi
+	async   atomic.Pointer[asyncQueue]
+	sampler atomic.Pointer[Sampler]
+	dropped atomic.Pointer[atomic.Uint64] // see Dropped
 	io.Closer
 }
 
@@ -75,10 +87,78 @@ func (l *Multi) WithName(name string) *Multi {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	return &Multi{
-		name: name,
-		logs: l.logs,
+	m := &Multi{
+		name:   name,
+		logs:   l.logs,
+		fields: l.fields,
+		level:  atomic.LoadInt32(&l.level),
+		parent: l.parent,
 	}
+	m.async.Store(l.async.Load())
+	m.sampler.Store(l.sampler.Load())
+	m.dropped.Store(l.dropped.Load())
+	return m
+}
+
+// With creates a new Multi Level Logger with the key/value fields
+// added to every message. See Fields for the arguments.
+func (l *Multi) With(kv ...any) *Multi {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fields := make([]Field, 0, len(l.fields)+len(kv)/2+1)
+	fields = append(fields, l.fields...)
+	fields = append(fields, Fields(kv...)...)
+	m := &Multi{
+		name:   l.name,
+		logs:   l.logs,
+		fields: fields,
+		level:  atomic.LoadInt32(&l.level),
+		parent: l.parent,
+	}
+	m.async.Store(l.async.Load())
+	m.sampler.Store(l.sampler.Load())
+	m.dropped.Store(l.dropped.Load())
+	return m
+}
+
+// SetLevel sets the minimum level of the logger. Messages below the
+// level are discarded before they are formatted. Unknown levels are
+// ignored.
+func (l *Multi) SetLevel(level string) {
+	if n, ok := severity(level); ok {
+		atomic.StoreInt32(&l.level, n)
+	}
+}
+
+// ResetLevel removes the minimum level of the logger, so it inherits
+// the level of its parent, see Get.
+func (l *Multi) ResetLevel() {
+	atomic.StoreInt32(&l.level, noLevel)
+}
+
+// minLevel returns the severity of the minimum level, walking up the
+// parents for loggers without their own level.
+func (l *Multi) minLevel() int32 {
+	for ; l != nil; l = l.parent {
+		if n := atomic.LoadInt32(&l.level); n != noLevel {
+			return n
+		}
+	}
+	return noLevel
+}
+
+// Level returns the minimum level of the logger.
+func (l *Multi) Level() string {
+	return levelOf(l.minLevel())
+}
+
+// Enabled reports whether the logger would output a message at the
+// level. It does not lock the logger so it is cheap enough to guard
+// expensive arguments in hot loops. Unknown levels are always enabled.
+func (l *Multi) Enabled(level string) bool {
+	n, ok := severity(level)
+	return !ok || n >= l.minLevel()
 }
 
 // CopyFrom deletes previous loggers and copy from new logger.
@@ -95,10 +175,16 @@ func (l *Multi) CopyFrom(in *Multi) {
 		l.logs[key] = value
 	}
 	l.Closer = in.Closer
+	atomic.StoreInt32(&l.level, atomic.LoadInt32(&in.level))
 }
 
-// Close the logger writer if l.Clogser is set.
+// Close the logger writer if l.Clogser is set. The records queued in
+// the asynchronous mode are written before.
 func (l *Multi) Close() {
+	if q := l.async.Swap(nil); q != nil {
+		q.close()
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -112,34 +198,141 @@ func (l *Multi) Close() {
 	}
 }
 
-func (l *Multi) Loutput(calldepth int, level string, v ...any) error {
+// lookup returns the output of the level, walking up the parents
+// for loggers without their own output. A level added by RegisterLevel
+// without output uses the output of the closest lower level, so the
+// loggers created before the registration handle it. It must be called
+// with l.mu held.
+func (l *Multi) lookup(level string) (Outputter, bool) {
+	if ll, ok := l.inherited(level); ok {
+		return ll, ok
+	}
+	for _, lower := range lowerLevels(level) {
+		if ll, ok := l.inherited(lower); ok {
+			return ll, ok
+		}
+	}
+	return nil, false
+}
+
+func (l *Multi) inherited(level string) (Outputter, bool) {
+	if ll, ok := l.logs[level]; ok || l.parent == nil {
+		return ll, ok
+	}
+
+	p := l.parent
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.inherited(level)
+}
+
+func (l *Multi) record(calldepth int, level, msg string, fields []Field) *Record {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	var pcs [1]uintptr
+	runtime.Callers(2+calldepth, pcs[:])
+	return &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Name:    l.name,
+		Message: msg,
+		Fields:  all,
+		PC:      pcs[0],
+	}
+}
+
+func (l *Multi) output(calldepth int, level, msg string, fields []Field) error {
+	if q := l.async.Load(); q != nil {
+		return q.push(l, l.record(1+calldepth, level, msg, fields))
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if ll, ok := l.logs[level]; ok {
-		return ll.Output(2+calldepth, level+l.name+fmt.Sprint(v...))
+	ll, ok := l.lookup(level)
+	if !ok {
+		return errOutput
 	}
-	return errOutput
+	if ro, ok := ll.(RecordOutputter); ok {
+		return ro.OutputRecord(2+calldepth, l.record(1+calldepth, level, msg, fields))
+	}
+	return ll.Output(2+calldepth, level+l.name+appendFields(msg, l.fields, fields))
 }
 
-func (l *Multi) Loutputf(calldepth int, level string, format string, v ...any) error {
+// outputRecord outputs the record built by the caller, which already
+// has the fields of the logger, like output.
+func (l *Multi) outputRecord(calldepth int, r *Record) error {
+	if q := l.async.Load(); q != nil {
+		return q.push(l, r)
+	}
+	return l.write(1+calldepth, r)
+}
+
+// write outputs the record built by output, which already has the
+// fields of the logger.
+func (l *Multi) write(calldepth int, r *Record) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if ll, ok := l.logs[level]; ok {
-		return ll.Output(2+calldepth, level+l.name+fmt.Sprintf(format, v...))
+	ll, ok := l.lookup(r.Level)
+	if !ok {
+		return errOutput
+	}
+	if ro, ok := ll.(RecordOutputter); ok {
+		return ro.OutputRecord(2+calldepth, r)
+	}
+	return ll.Output(2+calldepth, r.text())
+}
+
+func (l *Multi) Loutput(calldepth int, level string, v ...any) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	msg := fmt.Sprint(v...)
+	if l.sampled(level, msg) {
+		return nil
+	}
+	return l.output(1+calldepth, level, msg, nil)
+}
+
+func (l *Multi) Loutputf(calldepth int, level string, format string, v ...any) error {
+	if !l.Enabled(level) || l.sampled(level, format) {
+		return nil
 	}
-	return errOutput
+	return l.output(1+calldepth, level, fmt.Sprintf(format, v...), nil)
 }
 
 func (l *Multi) Loutputln(calldepth int, level string, v ...any) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	if !l.Enabled(level) {
+		return nil
+	}
+	msg := fmt.Sprintln(v...)
+	if l.sampled(level, msg) {
+		return nil
+	}
+	return l.output(1+calldepth, level, msg, nil)
+}
 
-	if ll, ok := l.logs[level]; ok {
-		return ll.Output(2+calldepth, level+l.name+fmt.Sprintln(v...))
+// Loutputw logs msg with the key/value fields. See Fields for the arguments.
+func (l *Multi) Loutputw(calldepth int, level string, msg string, kv ...any) error {
+	if !l.Enabled(level) || l.sampled(level, msg) {
+		return nil
 	}
-	return errOutput
+	return l.output(1+calldepth, level, msg, Fields(kv...))
+}
+
+// LoutputCtx logs the message with the fields registered for ctx.
+func (l *Multi) LoutputCtx(calldepth int, ctx context.Context, level string, v ...any) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	msg := fmt.Sprint(v...)
+	if l.sampled(level, msg) {
+		return nil
+	}
+	return l.output(1+calldepth, level, msg, contextFields(ctx))
 }
 
 func (l *Multi) Output(calldepth int, s string) error {
@@ -158,6 +351,14 @@ func (l *Multi) Traceln(v ...any) {
 	l.Loutputln(1, Ltrace, v...)
 }
 
+func (l *Multi) Tracew(msg string, kv ...any) {
+	l.Loutputw(1, Ltrace, msg, kv...)
+}
+
+func (l *Multi) TraceCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Ltrace, v...)
+}
+
 func (l *Multi) Debug(v ...any) {
 	l.Loutput(1, Ldebug, v...)
 }
@@ -170,6 +371,14 @@ func (l *Multi) Debugln(v ...any) {
 	l.Loutputln(1, Ldebug, v...)
 }
 
+func (l *Multi) Debugw(msg string, kv ...any) {
+	l.Loutputw(1, Ldebug, msg, kv...)
+}
+
+func (l *Multi) DebugCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Ldebug, v...)
+}
+
 func (l *Multi) Info(v ...any) {
 	l.Loutput(1, Linfo, v...)
 }
@@ -182,6 +391,14 @@ func (l *Multi) Infoln(v ...any) {
 	l.Loutputln(1, Linfo, v...)
 }
 
+func (l *Multi) Infow(msg string, kv ...any) {
+	l.Loutputw(1, Linfo, msg, kv...)
+}
+
+func (l *Multi) InfoCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Linfo, v...)
+}
+
 func (l *Multi) Warn(v ...any) {
 	l.Loutput(1, Lwarn, v...)
 }
@@ -194,6 +411,14 @@ func (l *Multi) Warnln(v ...any) {
 	l.Loutputln(1, Lwarn, v...)
 }
 
+func (l *Multi) Warnw(msg string, kv ...any) {
+	l.Loutputw(1, Lwarn, msg, kv...)
+}
+
+func (l *Multi) WarnCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Lwarn, v...)
+}
+
 func (l *Multi) Error(v ...any) {
 	l.Loutput(1, Lerror, v...)
 }
@@ -206,6 +431,14 @@ func (l *Multi) Errorln(v ...any) {
 	l.Loutputln(1, Lerror, v...)
 }
 
+func (l *Multi) Errorw(msg string, kv ...any) {
+	l.Loutputw(1, Lerror, msg, kv...)
+}
+
+func (l *Multi) ErrorCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Lerror, v...)
+}
+
 func (l *Multi) Print(v ...any) {
 	l.Loutput(1, Linfo, v...)
 }
@@ -235,3 +468,15 @@ func (l *Multi) Fatalln(v ...any) {
 	l.Close()
 	os.Exit(1)
 }
+
+func (l *Multi) Fatalw(msg string, kv ...any) {
+	l.Loutputw(1, Lfatal, msg, kv...)
+	l.Close()
+	os.Exit(1)
+}
+
+func (l *Multi) FatalCtx(ctx context.Context, v ...any) {
+	l.LoutputCtx(1, ctx, Lfatal, v...)
+	l.Close()
+	os.Exit(1)
+}
@@ -50,6 +50,82 @@ func TestLoggerNew(t *testing.T) {
 	}
 }
 
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", log.New(&buf, "", 0)).With("request", 42)
+	l.Infow("This is info", "user", "bob smith", "ok", true)
+	if want, got := "INFO main: This is info request=42 user=\"bob smith\" ok=true\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	l.WithName("sub: ").Infoln("This is info")
+	if want, got := "INFO sub: This is info request=42\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	l.Warnw("odd", 7, "dangling")
+	if want, got := "WARN main: odd request=42 !BADKEY=7 !BADKEY=dangling\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+}
+
+type recordOutput struct {
+	records []Record
+}
+
+func (o *recordOutput) Output(calldepth int, s string) error {
+	o.records = append(o.records, Record{Message: s})
+	return nil
+}
+
+func (o *recordOutput) OutputRecord(calldepth int, r *Record) error {
+	o.records = append(o.records, *r)
+	return nil
+}
+
+func TestRecordOutputter(t *testing.T) {
+	var out recordOutput
+	l := New("main: ", &out).With("request", 42)
+	l.Errorw("failed", "err", "timeout")
+	if len(out.records) != 1 {
+		t.Fatalf("logger should output 1 record, got %d", len(out.records))
+	}
+	r := out.records[0]
+	if r.Level != Lerror || r.Name != "main: " || r.Message != "failed" {
+		t.Errorf("record should match %q %q %q is %+v", Lerror, "main: ", "failed", r)
+	}
+	if want, got := []Field{{"request", 42}, {"err", "timeout"}}, r.Fields; len(want) != len(got) || want[0] != got[0] || want[1] != got[1] {
+		t.Errorf("record fields should match %v is %v", want, got)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("main: ", log.New(&buf, "", 0))
+	if want, got := Ltrace, l.Level(); want != got {
+		t.Errorf("logger level should be %q is %q", want, got)
+	}
+
+	l.SetLevel(Lwarn)
+	if l.Enabled(Linfo) || !l.Enabled(Lwarn) || !l.Enabled(Lfatal) {
+		t.Errorf("logger level %q enables wrong levels", l.Level())
+	}
+	l.Info("This is info")
+	l.Debugw("This is debug", "key", "value")
+	l.WithName("sub: ").Info("This is info")
+	l.Error("This is error")
+	if want, got := "ERROR main: This is error\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+
+	l.SetLevel("unknown")
+	if want, got := Lwarn, l.Level(); want != got {
+		t.Errorf("logger level should be %q is %q", want, got)
+	}
+}
+
 func BenchmarkStdlogPrint(b *testing.B) {
 	const testString = "test"
 	var buf bytes.Buffer
@@ -91,3 +167,57 @@ func BenchmarkInfoPtr(b *testing.B) {
 	}
 	b.StopTimer()
 }
+
+func BenchmarkDisabled(b *testing.B) {
+	const testString = "test"
+	var buf bytes.Buffer
+
+	l := New("", log.New(&buf, "", log.LstdFlags))
+	l.SetLevel(Linfo)
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("%s %d", testString, i)
+	}
+	b.StopTimer()
+}
+
+func TestGet(t *testing.T) {
+	var buf bytes.Buffer
+	db := Get("db")
+	pool := Get("db.pool")
+	if Get("db.pool") != pool || pool.parent != db || db.parent != Global() {
+		t.Fatalf("registry should build a tree of loggers")
+	}
+	for _, level := range LevelStrings {
+		db.SetOutput(level, log.New(&buf, "", 0))
+	}
+	db.SetLevel(Lwarn)
+
+	pool.Info("This is info")
+	pool.Warn("This is warn")
+	if want, got := "WARN db.pool: This is warn\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	db.SetLevel(Ldebug)
+	Get("db.migrate").Debug("This is debug")
+	if want, got := "DEBG db.migrate: This is debug\n", buf.String(); want != got {
+		t.Errorf("logger output should match %q is %q", want, got)
+	}
+
+	pool.SetLevel(Lerror)
+	if pool.Enabled(Lwarn) || !db.Enabled(Lwarn) {
+		t.Errorf("logger level should be overridden")
+	}
+	pool.ResetLevel()
+	if want, got := Ldebug, pool.Level(); want != got {
+		t.Errorf("logger level should be %q is %q", want, got)
+	}
+
+	names := Names()
+	if len(names) < 3 || names[0] != "db" || names[1] != "db.migrate" || names[2] != "db.pool" {
+		t.Errorf("registry names should be sorted, got %v", names)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Multi)
+)
+
+// Get returns the logger registered with the dotted name, creating it
+// and its parents if necessary. The parent of "db.pool" is "db", the
+// parent of "db" is the standard logger, which is also returned for
+// the empty name.
+//
+// A registered logger inherits the level and the outputs of its
+// parent until they are set with SetLevel and SetOutput, so changing
+// "db" also changes "db.pool" and "db.migrate". ResetLevel and Close
+// make the logger inherit again.
+func Get(name string) *Multi {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return get(name)
+}
+
+func get(name string) *Multi {
+	if name == "" {
+		return global
+	}
+	if l, ok := registry[name]; ok {
+		return l
+	}
+
+	parent := global
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		parent = get(name[:i])
+	}
+	l := &Multi{
+		name:   name + ": ",
+		logs:   make(map[string]Outputter),
+		level:  noLevel,
+		parent: parent,
+	}
+	registry[name] = l
+	return l
+}
+
+// Names returns the sorted names of the registered loggers.
+func Names() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2022-present ccpaging <ccpaging@gmail.com>. All Rights Reserved.
+// See License.txt for license information.
+
+package multi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sampler limits repeated messages. In every interval it lets the
+// first messages of a level and template pass, then every thereafter
+// message. The number of suppressed messages is logged at the end of
+// the interval, so nothing disappears silently.
+type Sampler struct {
+	first      int
+	thereafter int
+	interval   time.Duration
+
+	mu     sync.Mutex
+	counts map[sampleKey]*sampleCount
+	end    time.Time
+	timer  *time.Timer
+}
+
+type sampleKey struct {
+	name  string // the logger, so modules do not share a budget
+	level string
+	text  string
+}
+
+type sampleCount struct {
+	n          int
+	suppressed int
+	report     func(n int)
+}
+
+// NewSampler creates a sampler passing the first messages per interval,
+// then every thereafter message. If thereafter <= 0, all messages
+// after the first are suppressed until the interval ends.
+func NewSampler(first, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		counts:     make(map[sampleKey]*sampleCount),
+	}
+}
+
+func suppressed(n int, text string) string {
+	return fmt.Sprintf("suppressed %d messages like %q", n, text)
+}
+
+// allow reports whether the message passes. Otherwise report is called
+// with the number of suppressed messages when the interval ends.
+func (s *Sampler) allow(name, level, text string, report func(n int)) bool {
+	s.mu.Lock()
+	now := time.Now()
+	var reports []func()
+	if now.After(s.end) {
+		reports = s.reset()
+		s.end = now.Add(s.interval)
+	}
+
+	k := sampleKey{name, level, text}
+	c, ok := s.counts[k]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[k] = c
+	}
+	c.n++
+	pass := c.n <= s.first || s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0
+	if !pass {
+		c.suppressed++
+		c.report = report
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.end.Sub(now), s.tick)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, fn := range reports {
+		fn()
+	}
+	return pass
+}
+
+// reset clears the counts and returns the reports of the suppressed
+// messages. It must be called with s.mu held.
+func (s *Sampler) reset() (reports []func()) {
+	for _, c := range s.counts {
+		if c.suppressed > 0 {
+			report, n := c.report, c.suppressed
+			reports = append(reports, func() { report(n) })
+		}
+	}
+	if len(s.counts) > 0 {
+		s.counts = make(map[sampleKey]*sampleCount)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return
+}
+
+func (s *Sampler) tick() {
+	s.mu.Lock()
+	reports := s.reset()
+	s.end = time.Time{}
+	s.mu.Unlock()
+
+	for _, fn := range reports {
+		fn()
+	}
+}
+
+// Wrap returns an Outputter which samples the messages written to out.
+// Messages are keyed by logger name, level and text for a
+// RecordOutputter, and by text, which has the name and the level,
+// otherwise.
+func (s *Sampler) Wrap(out Outputter) Outputter {
+	return &sampledOutput{s: s, out: out}
+}
+
+type sampledOutput struct {
+	s   *Sampler
+	out Outputter
+}
+
+func (o *sampledOutput) Output(calldepth int, s string) error {
+	if !o.s.allow("", "", s, func(n int) { o.out.Output(1, suppressed(n, s)) }) {
+		return nil
+	}
+	return o.out.Output(1+calldepth, s)
+}
+
+func (o *sampledOutput) OutputRecord(calldepth int, r *Record) error {
+	ro, ok := o.out.(RecordOutputter)
+	if !ok {
+		return o.Output(1+calldepth, r.text())
+	}
+	if !o.s.allow(r.Name, r.Level, r.Message, func(n int) {
+		ro.OutputRecord(1, &Record{
+			Time:    time.Now(),
+			Level:   r.Level,
+			Name:    r.Name,
+			Message: suppressed(n, r.Message),
+		})
+	}) {
+		return nil
+	}
+	return ro.OutputRecord(1+calldepth, r)
+}
+
+// SetSampler samples the messages of the logger by name, level and
+// template before they are formatted. The template is the format of the
+// Printf-like methods and the message otherwise. A nil s disables
+// sampling. Loggers derived with WithName or With afterwards share
+// the sampler.
+func (l *Multi) SetSampler(s *Sampler) {
+	l.sampler.Store(s)
+}
+
+// sampled reports whether the sampler of the logger suppresses the
+// message with the template.
+func (l *Multi) sampled(level, template string) bool {
+	s := l.sampler.Load()
+	if s == nil {
+		return false
+	}
+	return !s.allow(l.name, level, template, func(n int) {
+		l.output(1, level, suppressed(n, template), nil)
+	})
+}
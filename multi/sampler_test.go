@@ -0,0 +1,126 @@
+package multi
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer written by the timer of the sampler.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+type syncRecords struct {
+	mu sync.Mutex
+	recordOutput
+}
+
+func (o *syncRecords) Output(calldepth int, s string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.recordOutput.Output(calldepth, s)
+}
+
+func (o *syncRecords) OutputRecord(calldepth int, r *Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.recordOutput.OutputRecord(calldepth, r)
+}
+
+func (o *syncRecords) get() []Record {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Record(nil), o.records...)
+}
+
+func TestSampler(t *testing.T) {
+	var buf syncBuffer
+	l := New("main: ", log.New(&buf, "", 0))
+	l.SetSampler(NewSampler(2, 3, 50*time.Millisecond))
+
+	for i := 0; i < 10; i++ {
+		l.Warnf("disk %d full", i)
+	}
+	l.Error("other")
+	if want, got := "WARN main: disk 0 full\nWARN main: disk 1 full\nWARN main: disk 4 full\nWARN main: disk 7 full\nERROR main: other\n", buf.String(); want != got {
+		t.Errorf("sampled output should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	time.Sleep(100 * time.Millisecond)
+	if want, got := "WARN main: suppressed 6 messages like \"disk %d full\"\n", buf.String(); want != got {
+		t.Errorf("sampler summary should match %q is %q", want, got)
+	}
+	buf.Reset()
+
+	l.Warnf("disk %d full", 10)
+	if want, got := "WARN main: disk 10 full\n", buf.String(); want != got {
+		t.Errorf("sampler should pass messages of a new interval, got %q", got)
+	}
+}
+
+func TestSamplerWrap(t *testing.T) {
+	var buf syncBuffer
+	s := NewSampler(1, 0, 50*time.Millisecond)
+	l := New("main: ", s.Wrap(log.New(&buf, "", 0)))
+
+	for i := 0; i < 5; i++ {
+		l.Info("same")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if want, got := "INFO main: same\nsuppressed 4 messages like \"INFO main: same\"\n", buf.String(); want != got {
+		t.Errorf("sampled output should match %q is %q", want, got)
+	}
+
+	var out syncRecords
+	l = New("main: ", s.Wrap(&out))
+	for i := 0; i < 3; i++ {
+		l.Error("failed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	records := out.get()
+	if want, got := 2, len(records); want != got {
+		t.Fatalf("sampled output should have %d records, got %d", want, got)
+	}
+	if r := records[1]; r.Level != Lerror || !strings.HasPrefix(r.Message, "suppressed 2 messages") {
+		t.Errorf("sampler summary should be an error record, got %+v", r)
+	}
+}
+
+func TestSamplerNames(t *testing.T) {
+	var buf syncBuffer
+	l := New("main: ", log.New(&buf, "", 0))
+	l.SetSampler(NewSampler(1, 0, time.Minute))
+
+	db, http := l.WithName("db: "), l.WithName("http: ")
+	for i := 0; i < 3; i++ {
+		db.Warn("timeout")
+		http.Warn("timeout")
+	}
+	if want, got := "WARN db: timeout\nWARN http: timeout\n", buf.String(); want != got {
+		t.Errorf("loggers should be sampled apart, %q is %q", want, got)
+	}
+}
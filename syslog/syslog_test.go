@@ -14,6 +14,7 @@ import (
 	"net"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -417,3 +418,62 @@ func TestConcurrentReconnect(t *testing.T) {
 		t.Error("timeout in concurrent reconnect")
 	}
 }
+
+func TestWritePriority(t *testing.T) {
+	done := make(chan string)
+	addr, sock, srvWG := startServer("udp", "", done)
+	defer srvWG.Wait()
+	defer sock.Close()
+
+	w, err := Dial("udp", addr, LOG_LOCAL0|LOG_INFO, "syslog_test")
+	if err != nil {
+		t.Fatalf("syslog.Dial() failed: %v", err)
+	}
+	defer w.Close()
+	if _, err := w.WritePriority(LOG_USER|LOG_WARNING, "priority test"); err != nil {
+		t.Fatalf("WritePriority() failed: %v", err)
+	}
+	if rcvd, want := <-done, fmt.Sprintf("<%d>", LOG_LOCAL0|LOG_WARNING); !strings.HasPrefix(rcvd, want) {
+		t.Errorf("got %q, want prefix %q", rcvd, want)
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// the daemon is down
+	w, err := NewWriter("tcp", addr, LOG_USER|LOG_INFO, "syslog_test")
+	if err != nil {
+		t.Fatalf("NewWriter() failed: %v", err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("lost")); err == nil {
+		t.Errorf("write to a closed port should fail")
+	}
+
+	// the daemon is up
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	if _, err := w.Write([]byte("delayed")); err != nil {
+		t.Fatalf("write after the daemon is up failed: %v", err)
+	}
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _ := c.Read(buf)
+	if got := string(buf[:n]); !strings.Contains(got, "delayed") {
+		t.Errorf("got %q, want delayed", got)
+	}
+}
@@ -52,6 +52,25 @@ type netConn struct {
 // Otherwise, see the documentation for net.Dial for valid values
 // of network and raddr.
 func Dial(network, raddr string, priority Priority, tag string) (*Writer, error) {
+	w, err := NewWriter(network, raddr, priority, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err = w.connect()
+	if err != nil {
+		return nil, err
+	}
+	return w, err
+}
+
+// NewWriter is like Dial, but connects on the first write, so the
+// writer can be created while the log daemon is down. Every write
+// connects again until the daemon is reachable.
+func NewWriter(network, raddr string, priority Priority, tag string) (*Writer, error) {
 	if priority < 0 || priority > LOG_LOCAL7|LOG_DEBUG {
 		return nil, errors.New("log/syslog: invalid priority")
 	}
@@ -61,22 +80,13 @@ func Dial(network, raddr string, priority Priority, tag string) (*Writer, error)
 	}
 	hostname, _ := os.Hostname()
 
-	w := &Writer{
+	return &Writer{
 		priority: priority,
 		tag:      tag,
 		hostname: hostname,
 		network:  network,
 		raddr:    raddr,
-	}
-
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	err := w.connect()
-	if err != nil {
-		return nil, err
-	}
-	return w, err
+	}, nil
 }
 
 // connect makes a connection to the syslog server.
@@ -114,6 +124,13 @@ func (w *Writer) Write(b []byte) (int, error) {
 	return w.writeAndRetry(w.priority, string(b))
 }
 
+// WritePriority sends a log message with the severity of p and the
+// facility of the writer to the syslog daemon, reconnecting if the
+// connection is lost.
+func (w *Writer) WritePriority(p Priority, s string) (int, error) {
+	return w.writeAndRetry(p, s)
+}
+
 // Close closes a connection to the syslog daemon.
 func (w *Writer) Close() error {
 	w.mu.Lock()